@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"wowarmory/internal/fakeupstream"
+)
+
+func main() {
+	// Get port from environment variable or use default
+	port := os.Getenv("FAKE_UPSTREAM_PORT")
+	if port == "" {
+		port = "3001"
+	}
+
+	baseURL := fmt.Sprintf("http://localhost:%s", port)
+	upstream := fakeupstream.UpstreamConfig(baseURL)
+
+	// Print the environment needed to point the server at the fake upstream
+	fmt.Printf("Fake upstream listening on %s\n", baseURL)
+	fmt.Println("Run the server with:")
+	fmt.Printf("  CLIENT_ID=%s\n", fakeupstream.ClientID)
+	fmt.Printf("  CLIENT_SECRET=%s\n", fakeupstream.ClientSecret)
+	fmt.Printf("  WARCRAFTLOGS_CLIENT_ID=%s\n", fakeupstream.WarcraftlogsClientID)
+	fmt.Printf("  WARCRAFTLOGS_CLIENT_SECRET=%s\n", fakeupstream.WarcraftlogsClientSecret)
+	fmt.Printf("  WARCRAFTLOGS_API_TOKEN=%s\n", fakeupstream.WarcraftlogsToken)
+	fmt.Printf("  BLIZZARD_OAUTH_URL=%s\n", upstream.BlizzardOAuthURL)
+	fmt.Printf("  BLIZZARD_API_URL=%s\n", upstream.BlizzardAPIURL)
+	fmt.Printf("  WARCRAFTLOGS_OAUTH_URL=%s\n", upstream.WarcraftlogsOAuthURL)
+	fmt.Printf("  WARCRAFTLOGS_API_URL=%s\n", upstream.WarcraftlogsAPIURL)
+
+	if err := http.ListenAndServe(":"+port, fakeupstream.NewHandler()); err != nil {
+		log.Fatalf("Failed to start fake upstream: %v", err)
+	}
+}
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"wowarmory/internal/api"
+	"wowarmory/internal/cache"
 	"wowarmory/internal/config"
 	"wowarmory/internal/handlers"
 	"wowarmory/internal/interfaces"
+	"wowarmory/internal/logging"
 	"wowarmory/internal/middleware"
 	"wowarmory/internal/redis"
 	"wowarmory/internal/router"
@@ -16,60 +23,136 @@ import (
 )
 
 func main() {
+	if err := run(); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// run starts the server and blocks until it is stopped by SIGINT or SIGTERM
+func run() error {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Create API clients
-	blizzardClient := api.NewBlizzardClient(cfg.ClientID, cfg.ClientSecret)
-	warcraftlogsClient := api.NewWarcraftlogsClient(cfg.WarcraftlogsAPIToken)
-	tokenClient := api.NewTokenClient(cfg.ClientID, cfg.ClientSecret)
+	// Create the structured logger used by the middleware, handlers and API clients
+	logger := logging.New(cfg.Log.Format, cfg.Log.Level)
+	slog.SetDefault(logger)
 
 	// Create Redis client
 	redisClient, err := redis.NewClient(&cfg.Redis)
 	if err != nil {
-		log.Fatalf("Failed to create Redis client: %v", err)
+		return fmt.Errorf("failed to create Redis client: %w", err)
+	}
+	defer closeSearchStore(logger, redisClient)
+
+	// Create API clients sharing a single Blizzard access token and region budget, caching responses in Redis
+	blizzardTokens := api.NewAccessTokenProvider(cfg.Upstream.BlizzardOAuthURL, cfg.ClientID, cfg.ClientSecret)
+	blizzardBudget := api.NewRegionBudget(cfg.Upstream.BlizzardAPIURL,
+		cfg.Upstream.BlizzardRegionBudget.Requests, cfg.Upstream.BlizzardRegionBudget.Period)
+	blizzardClient := cache.NewBlizzardCache(
+		api.NewBlizzardClient(cfg.Upstream.BlizzardAPIURL, blizzardTokens, blizzardBudget), redisClient, cfg.Cache)
+	var warcraftlogsTokens *api.AccessTokenProvider
+	if cfg.WarcraftlogsClientID != "" {
+		warcraftlogsTokens = api.NewWarcraftlogsTokenProvider(cfg.Upstream.WarcraftlogsOAuthURL,
+			cfg.WarcraftlogsClientID, cfg.WarcraftlogsClientSecret)
 	}
-	defer redisClient.Close()
+	warcraftlogsClient := cache.NewWarcraftlogsCache(
+		api.NewWarcraftlogsClient(cfg.Upstream.WarcraftlogsAPIURL, warcraftlogsTokens, cfg.WarcraftlogsAPIToken, cfg.Warcraftlogs),
+		redisClient, cfg.Cache)
+	tokenClient := api.NewTokenClient(cfg.Upstream.BlizzardAPIURL, blizzardTokens, blizzardBudget)
 
 	// Create template manager
 	templateMgr, err := templates.NewManager(cfg.TemplatesDir)
 	if err != nil {
-		log.Fatalf("Failed to create template manager: %v", err)
+		return fmt.Errorf("failed to create template manager: %w", err)
 	}
 
 	// Create base handler
 	baseHandler := handlers.NewBaseHandler(cfg, redisClient, templateMgr)
 
 	// Create handlers
-	characterHandler := handlers.NewCharacterHandler(baseHandler, blizzardClient)
-	guildHandler := handlers.NewGuildHandler(baseHandler, warcraftlogsClient)
+	characterHandler := handlers.NewCharacterHandler(baseHandler, blizzardClient, warcraftlogsClient)
+	guildHandler := handlers.NewGuildHandler(baseHandler, blizzardClient, warcraftlogsClient)
+	reportHandler := handlers.NewReportHandler(baseHandler, warcraftlogsClient)
 	recentSearchesHandler := handlers.NewRecentSearchesHandler(baseHandler)
 	tokenHandler := handlers.NewTokenHandler(baseHandler, tokenClient)
+	apiHandler := handlers.NewAPIHandler(baseHandler, blizzardClient, warcraftlogsClient, tokenClient)
+	metricsHandler := handlers.NewMetricsHandler(baseHandler)
+	healthHandler := handlers.NewHealthHandler(baseHandler, blizzardClient, warcraftlogsClient)
 
 	// Collect all handlers
 	appHandlers := []interfaces.Handler{
 		characterHandler,
 		guildHandler,
+		reportHandler,
 		recentSearchesHandler,
 		tokenHandler,
+		apiHandler,
+		metricsHandler,
+		healthHandler,
 	}
 
 	// Create router
 	r := router.New(cfg)
 	r.SetupHandlers(appHandlers)
 
-	// Wrap router with middleware
-	handler := middleware.RecoveryMiddleware(
-		middleware.LoggingMiddleware(r),
+	// Wrap router with middleware, recovery runs inside logging so panics are logged with the request ID
+	// and rate limiting runs inside metrics so rejected requests are counted
+	handler := middleware.LoggingMiddleware(logger,
+		middleware.MetricsMiddleware(
+			middleware.RateLimitMiddleware(redisClient, cfg.RateLimit,
+				middleware.RecoveryMiddleware(r),
+			),
+		),
 	)
 
+	// Stop on SIGINT and SIGTERM, a second signal kills the process immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
 	// Start server
-	addr := fmt.Sprintf(":%d", cfg.Port)
-	fmt.Printf("Listening on http://localhost%s\n", addr)
-	if err := http.ListenAndServe(addr, handler); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("listening", "url", "http://localhost"+server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-ctx.Done():
+		stop()
+	}
+
+	// Stop accepting connections and let in-flight lookups finish before closing the store they use
+	logger.Info("shutting down", "timeout", cfg.Server.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("failed to drain requests before the shutdown timeout", "error", err)
+		server.Close()
+	}
+
+	logger.Info("server stopped")
+	return nil
+}
+
+// closeSearchStore closes the search store, logging failures since the process is exiting anyway
+func closeSearchStore(logger *slog.Logger, store interfaces.SearchStore) {
+	if err := store.Close(); err != nil {
+		logger.Error("failed to close search store", "error", err)
 	}
 }
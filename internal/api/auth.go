@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"wowarmory/internal/logging"
+)
+
+// tokenExpiryMargin is how long before expiry a cached token is refreshed
+const tokenExpiryMargin = 5 * time.Minute
+
+// fallbackTokenLifetime is how long a token is cached when the token endpoint does not say when it expires,
+// a token that expires earlier is rejected with a 401 and fetched again
+const fallbackTokenLifetime = 10 * time.Minute
+
+// tokenRequestTimeout bounds a token refresh, it is not tied to the request that started it
+const tokenRequestTimeout = 10 * time.Second
+
+// Client names of the token requests in logs, metrics and errors
+const (
+	blizzardOAuthClientName     = "BlizzardOAuth"
+	warcraftlogsOAuthClientName = "WarcraftLogsOAuth"
+)
+
+// AccessTokenProvider fetches and caches OAuth access tokens using the client credentials flow.
+// It is safe for concurrent use and is meant to be shared by all clients of an API.
+type AccessTokenProvider struct {
+	name         string
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	inflight  *tokenCall
+}
+
+// tokenCall represents an in-flight token request that concurrent callers wait on
+type tokenCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// tokenResponse represents the response from the OAuth token endpoint
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// NewAccessTokenProvider creates a new access token provider for the given Blizzard OAuth token endpoint
+func NewAccessTokenProvider(tokenURL, clientID, clientSecret string) *AccessTokenProvider {
+	return newAccessTokenProvider(blizzardOAuthClientName, tokenURL, clientID, clientSecret)
+}
+
+// NewWarcraftlogsTokenProvider creates a new access token provider for the given Warcraftlogs OAuth token endpoint
+func NewWarcraftlogsTokenProvider(tokenURL, clientID, clientSecret string) *AccessTokenProvider {
+	return newAccessTokenProvider(warcraftlogsOAuthClientName, tokenURL, clientID, clientSecret)
+}
+
+// newAccessTokenProvider creates a new access token provider named name in logs, metrics and errors
+func newAccessTokenProvider(name, tokenURL, clientID, clientSecret string) *AccessTokenProvider {
+	return &AccessTokenProvider{
+		name:         name,
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient:   newHTTPClient(name, httpClientOptions{}),
+	}
+}
+
+// Token returns a valid access token, fetching a new one if the cached token
+// is missing or about to expire. Concurrent callers share a single refresh, which keeps
+// running when the caller that started it gives up so the other callers still get the token.
+func (p *AccessTokenProvider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	if p.token != "" && time.Now().Before(p.expiresAt) {
+		token := p.token
+		p.mu.Unlock()
+		return token, nil
+	}
+
+	// Wait for a refresh that is already in progress
+	if call := p.inflight; call != nil {
+		p.mu.Unlock()
+		return call.wait(ctx, p.name)
+	}
+
+	call := &tokenCall{done: make(chan struct{})}
+	p.inflight = call
+	p.mu.Unlock()
+
+	go p.refresh(context.WithoutCancel(ctx), call)
+	return call.wait(ctx, p.name)
+}
+
+// refresh fetches a new access token for an in-flight token call
+func (p *AccessTokenProvider) refresh(ctx context.Context, call *tokenCall) {
+	ctx, cancel := context.WithTimeout(ctx, tokenRequestTimeout)
+	defer cancel()
+
+	token, expiresIn, err := p.fetchToken(ctx)
+
+	if err == nil && expiresIn <= 0 {
+		logging.FromContext(ctx).Warn("access token without a lifetime, using the fallback lifetime", "client", p.name, "expires_in", expiresIn, "lifetime", fallbackTokenLifetime)
+	}
+
+	p.mu.Lock()
+	if err == nil {
+		p.token = token
+		p.expiresAt = time.Now().Add(tokenLifetime(expiresIn))
+	}
+	p.inflight = nil
+	p.mu.Unlock()
+
+	call.token, call.err = token, err
+	close(call.done)
+}
+
+// wait waits for the token call to finish or the context to be done
+func (call *tokenCall) wait(ctx context.Context, client string) (string, error) {
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return "", newRequestError(client, ctx.Err())
+	}
+}
+
+// Invalidate discards the cached token so the next call to Token fetches a new one
+func (p *AccessTokenProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = ""
+	p.expiresAt = time.Time{}
+}
+
+// fetchToken requests a new access token using the client credentials flow
+func (p *AccessTokenProvider) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	if p.clientID == "" || p.clientSecret == "" {
+		return "", 0, &Error{Kind: ErrUnauthorized, Client: p.name, Message: "missing client ID or client secret"}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.tokenURL, strings.NewReader("grant_type=client_credentials"))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(p.clientID, p.clientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", 0, newRequestError(p.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		statusErr := newStatusError(p.name, resp)
+		if statusErr.StatusCode == http.StatusBadRequest {
+			// The token endpoint answers invalid client credentials with 400 or 401
+			statusErr.Kind = ErrUnauthorized
+		}
+		return "", 0, statusErr
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var result tokenResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if result.AccessToken == "" {
+		return "", 0, fmt.Errorf("unable to get access token from response")
+	}
+	return result.AccessToken, time.Duration(result.ExpiresIn) * time.Second, nil
+}
+
+// tokenLifetime returns how long a token with the given lifetime may be cached, tokens without
+// a lifetime are cached for fallbackTokenLifetime instead of being fetched again on every call
+func tokenLifetime(expiresIn time.Duration) time.Duration {
+	if expiresIn <= 0 {
+		return fallbackTokenLifetime
+	}
+	if expiresIn <= 2*tokenExpiryMargin {
+		return expiresIn / 2
+	}
+	return expiresIn - tokenExpiryMargin
+}
@@ -1,20 +1,39 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
+	"time"
 	"wowarmory/internal/interfaces"
 )
 
+// mythicSeasonTTL is how long the current Mythic+ season of a region is cached, seasons only change a few times a year
+const mythicSeasonTTL = time.Hour
+
+// guildSlugger turns a lowercase guild name into its Blizzard slug, apostrophes are dropped and spaces become dashes
+var guildSlugger = strings.NewReplacer("'", "", "’", "", " ", "-")
+
 // BlizzardClient is a client for the Blizzard API
 type BlizzardClient struct {
-	clientID     string
-	clientSecret string
-	httpClient   *http.Client
+	baseURL    string
+	tokens     *AccessTokenProvider
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	seasons map[string]mythicSeason
+}
+
+// mythicSeason is the cached current Mythic+ season of a region
+type mythicSeason struct {
+	id        int
+	expiresAt time.Time
 }
 
 // Ensure BlizzardClient implements BlizzardAPI interface
@@ -25,125 +44,286 @@ func (c *BlizzardClient) GetClientName() string {
 	return "BlizzardAPI"
 }
 
-// NewBlizzardClient creates a new Blizzard API client
-func NewBlizzardClient(clientID, clientSecret string) *BlizzardClient {
-	return &BlizzardClient{
-		clientID:     clientID,
-		clientSecret: clientSecret,
-		httpClient:   &http.Client{},
+// NewBlizzardClient creates a new Blizzard API client using the shared token provider and region budget,
+// a nil budget does not limit the calls. The base URL may contain a {region} placeholder, e.g.
+// https://{region}.api.blizzard.com.
+func NewBlizzardClient(baseURL string, tokens *AccessTokenProvider, budget *RegionBudget) *BlizzardClient {
+	c := &BlizzardClient{
+		baseURL: baseURL,
+		tokens:  tokens,
+		seasons: make(map[string]mythicSeason),
 	}
+	c.httpClient = newHTTPClient(c.GetClientName(), httpClientOptions{budget: budget})
+	return c
 }
 
-// GetAccessToken gets an access token from the Blizzard API
-func (c *BlizzardClient) GetAccessToken() (string, error) {
-	if c.clientID == "" || c.clientSecret == "" {
-		return "", fmt.Errorf("missing client ID or client secret")
+// GetAccessToken returns a cached access token, refreshing it if needed
+func (c *BlizzardClient) GetAccessToken(ctx context.Context) (string, error) {
+	return c.tokens.Token(ctx)
+}
+
+// GetCharacterProfile gets a character and all of its profile resources from the Blizzard API.
+// Only the profile summary is required, failures of the other resources are recorded in the response.
+func (c *BlizzardClient) GetCharacterProfile(ctx context.Context, region, realm, character string) (interface{}, error) {
+	if region == "" || realm == "" || character == "" {
+		return nil, fmt.Errorf("missing region, realm, or character")
 	}
 
-	url := "https://oauth.battle.net/oauth/token"
-	req, err := http.NewRequest("POST", url, strings.NewReader("grant_type=client_credentials"))
+	accessToken, err := c.tokens.Token(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-	req.SetBasicAuth(c.clientID, c.clientSecret)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+	response := &CharacterResponse{
+		Errors: make(map[string]error),
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("failed to get access token: %s (status code: %d)", string(body), resp.StatusCode)
+	var mu sync.Mutex
+	recordError := func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		response.Errors[name] = err
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	// Fetch every resource concurrently, each request only writes its own field
+	var wg sync.WaitGroup
+	fetch := func(name string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				recordError(name, err)
+			}
+		}()
+	}
+
+	fetch(CharacterProfileRequest, func() error {
+		var profile CharacterProfile
+		header, err := c.fetchJSONHeader(ctx, c.profileURL(region, realm, character, ""), accessToken, &profile)
+		if err != nil {
+			return err
+		}
+		response.Profile = &profile
+		response.LastModified = header.Get("Last-Modified")
+		return nil
+	})
+	fetch(CharacterMediaRequest, func() error {
+		var media CharacterMedia
+		if err := c.fetchJSON(ctx, c.profileURL(region, realm, character, CharacterMediaRequest), accessToken, &media); err != nil {
+			return err
+		}
+		response.Media = &media
+		return nil
+	})
+	fetch(CharacterStatisticsRequest, func() error {
+		var statistics CharacterStatistics
+		if err := c.fetchJSON(ctx, c.profileURL(region, realm, character, CharacterStatisticsRequest), accessToken, &statistics); err != nil {
+			return err
+		}
+		response.Statistics = &statistics
+		return nil
+	})
+	fetch(CharacterEquipmentRequest, func() error {
+		var equipment EquipmentResponse
+		if err := c.fetchJSON(ctx, c.profileURL(region, realm, character, CharacterEquipmentRequest), accessToken, &equipment); err != nil {
+			return err
+		}
+		response.Equipment = &equipment
+		return nil
+	})
+	fetch(CharacterMythicRequest, func() error {
+		var mythic MythicKeystoneResponse
+		if err := c.fetchJSON(ctx, c.profileURL(region, realm, character, CharacterMythicRequest), accessToken, &mythic.Profile); err != nil {
+			return err
+		}
+		response.MythicKeystone = &mythic
+
+		// The overall rating is still usable when the season details fail to load
+		seasonID, err := c.currentMythicSeason(ctx, region, accessToken)
+		if err != nil {
+			recordError(CharacterMythicSeason, err)
+			return nil
+		}
+
+		// Characters that did not complete a keystone this season have no runs to show
+		if !mythic.Profile.PlayedSeason(seasonID) {
+			return nil
+		}
+
+		var season MythicKeystoneSeason
+		resource := fmt.Sprintf("%s/%d", CharacterMythicSeason, seasonID)
+		if err := c.fetchJSON(ctx, c.profileURL(region, realm, character, resource), accessToken, &season); err != nil {
+			recordError(CharacterMythicSeason, err)
+			return nil
+		}
+		mythic.Season = &season
+		return nil
+	})
+	fetch(CharacterRaidsRequest, func() error {
+		var raids RaidEncountersResponse
+		if err := c.fetchJSON(ctx, c.profileURL(region, realm, character, CharacterRaidsRequest), accessToken, &raids); err != nil {
+			return err
+		}
+		response.Raids = &raids
+		return nil
+	})
+	fetch(CharacterPvPRequest, func() error {
+		pvp, err := c.getPvPSummary(ctx, region, realm, character, accessToken, recordError)
+		if err != nil {
+			return err
+		}
+		response.PvP = pvp
+		return nil
+	})
+
+	wg.Wait()
+
+	// Without the profile summary there is no character to show
+	if response.Profile == nil {
+		return nil, response.Errors[CharacterProfileRequest]
+	}
+
+	return response, nil
+}
+
+// CharacterModifiedSince reports whether the profile of a character changed after the given
+// Last-Modified time, using a conditional request so unchanged characters are not downloaded again
+func (c *BlizzardClient) CharacterModifiedSince(ctx context.Context, region, realm, character, lastModified string) (bool, error) {
+	accessToken, err := c.tokens.Token(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return false, err
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.profileURL(region, realm, character, ""), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("If-Modified-Since", lastModified)
 
-	accessToken, ok := result["access_token"].(string)
-	if !ok {
-		return "", fmt.Errorf("unable to get access token from response")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, newRequestError(c.GetClientName(), err)
 	}
-	return accessToken, nil
-}
+	defer resp.Body.Close()
 
-// GetCharacterProfile gets a character profile from the Blizzard API
-func (c *BlizzardClient) GetCharacterProfile(accessToken, region, realm, character string) (map[string]interface{}, error) {
-	if accessToken == "" {
-		return nil, fmt.Errorf("missing access token")
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+		return true, nil
+	case http.StatusUnauthorized:
+		c.tokens.Invalidate()
 	}
-	if region == "" || realm == "" || character == "" {
-		return nil, fmt.Errorf("missing region, realm, or character")
+	return false, newStatusError(c.GetClientName(), resp)
+}
+
+// GetGuildRoster gets every member of a guild with their rank, level, class, race and faction from the Blizzard API
+func (c *BlizzardClient) GetGuildRoster(ctx context.Context, region, realm, guild string) (interface{}, error) {
+	if region == "" || realm == "" || guild == "" {
+		return nil, fmt.Errorf("missing region, realm, or guild")
 	}
 
-	// Define the endpoints to fetch data from
-	endpoints := []string{
-		fmt.Sprintf("https://%s.api.blizzard.com/profile/wow/character/%s/%s?namespace=profile-%s&locale=en_US&access_token=%s", region, realm, character, region, accessToken),
-		fmt.Sprintf("https://%s.api.blizzard.com/profile/wow/character/%s/%s/character-media?namespace=profile-%s&locale=en_US&access_token=%s", region, realm, character, region, accessToken),
-		fmt.Sprintf("https://%s.api.blizzard.com/profile/wow/character/%s/%s/statistics?namespace=profile-%s&locale=en_US&access_token=%s", region, realm, character, region, accessToken),
+	accessToken, err := c.tokens.Token(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create a channel to receive responses from goroutines
-	type apiResponse struct {
-		data map[string]interface{}
-		err  error
+	// Guild names are slugged like Blizzard does, without apostrophes and with dashes instead of spaces
+	slug := url.PathEscape(guildSlugger.Replace(strings.ToLower(strings.TrimSpace(guild))))
+	url := fmt.Sprintf("%s/data/wow/guild/%s/%s/roster?namespace=profile-%s&locale=en_US", regionURL(c.baseURL, region), realm, slug, region)
+
+	var response GuildRosterResponse
+	if err := c.fetchJSON(ctx, url, accessToken, &response); err != nil {
+		return nil, err
 	}
-	ch := make(chan apiResponse, len(endpoints))
+	return &response, nil
+}
 
-	// Create a wait group to wait for all goroutines to finish
-	var wg sync.WaitGroup
-	wg.Add(len(endpoints))
+// currentMythicSeason returns the ID of the current Mythic+ season of a region, cached for mythicSeasonTTL
+func (c *BlizzardClient) currentMythicSeason(ctx context.Context, region, accessToken string) (int, error) {
+	c.mu.Lock()
+	season, ok := c.seasons[region]
+	c.mu.Unlock()
+	if ok && time.Now().Before(season.expiresAt) {
+		return season.id, nil
+	}
 
-	// Fetch data from each endpoint concurrently
-	for _, url := range endpoints {
-		go func(url string) {
-			defer wg.Done()
-			data, err := c.fetchAPI(url, accessToken)
-			ch <- apiResponse{data, err}
-		}(url)
+	url := fmt.Sprintf("%s/data/wow/mythic-keystone/season/index?namespace=dynamic-%s&locale=en_US", regionURL(c.baseURL, region), region)
+	var index MythicKeystoneSeasonIndex
+	if err := c.fetchJSON(ctx, url, accessToken, &index); err != nil {
+		return 0, err
 	}
 
-	// Wait for all goroutines to finish and close the channel
-	go func() {
-		wg.Wait()
-		close(ch)
-	}()
+	c.mu.Lock()
+	c.seasons[region] = mythicSeason{id: index.CurrentSeason.ID, expiresAt: time.Now().Add(mythicSeasonTTL)}
+	c.mu.Unlock()
+	return index.CurrentSeason.ID, nil
+}
 
-	// Combine the data from all endpoints
-	combinedData := make(map[string]interface{})
-	var firstError error
+// getPvPSummary gets the PvP summary of a character and the statistics of every bracket it played this season.
+// Brackets that fail to load are left out and recorded as CharacterPvPBrackets, the rest of the summary is still useful.
+func (c *BlizzardClient) getPvPSummary(ctx context.Context, region, realm, character, accessToken string, recordError func(string, error)) (*PvPResponse, error) {
+	var response PvPResponse
+	if err := c.fetchJSON(ctx, c.profileURL(region, realm, character, CharacterPvPRequest), accessToken, &response.Summary); err != nil {
+		return nil, err
+	}
 
-	for response := range ch {
-		if response.err != nil {
-			if firstError == nil {
-				firstError = response.err
+	// The summary links to every bracket the character has played, including solo shuffle specs
+	slugs := response.Summary.BracketSlugs()
+	brackets := make([]*PvPBracket, len(slugs))
+
+	var wg sync.WaitGroup
+	wg.Add(len(slugs))
+	for i, slug := range slugs {
+		go func(i int, slug string) {
+			defer wg.Done()
+			var bracket PvPBracket
+			if err := c.fetchJSON(ctx, c.profileURL(region, realm, character, CharacterPvPBrackets+"/"+slug), accessToken, &bracket); err != nil {
+				// Brackets Blizzard no longer knows are not failures
+				if !errors.Is(err, ErrNotFound) {
+					recordError(CharacterPvPBrackets, err)
+				}
+				return
 			}
-			continue
-		}
-		for k, v := range response.data {
-			combinedData[k] = v
+			bracket.Slug = slug
+			brackets[i] = &bracket
+		}(i, slug)
+	}
+	wg.Wait()
+
+	for _, bracket := range brackets {
+		if bracket != nil {
+			response.Brackets = append(response.Brackets, *bracket)
 		}
 	}
 
-	if firstError != nil && len(combinedData) == 0 {
-		return nil, firstError
+	return &response, nil
+}
+
+// profileURL builds the URL of a character profile resource, an empty resource is the profile summary
+func (c *BlizzardClient) profileURL(region, realm, character, resource string) string {
+	path := fmt.Sprintf("/profile/wow/character/%s/%s", realm, character)
+	if resource != "" {
+		path += "/" + resource
 	}
+	return fmt.Sprintf("%s%s?namespace=profile-%s&locale=en_US", regionURL(c.baseURL, region), path, region)
+}
+
+// regionURL replaces the {region} placeholder of a Blizzard API base URL
+func regionURL(baseURL, region string) string {
+	return strings.ReplaceAll(baseURL, "{region}", region)
+}
 
-	return combinedData, nil
+// fetchJSON fetches a Blizzard API endpoint and decodes the JSON response into v
+func (c *BlizzardClient) fetchJSON(ctx context.Context, url, accessToken string, v interface{}) error {
+	_, err := c.fetchJSONHeader(ctx, url, accessToken, v)
+	return err
 }
 
-// fetchAPI fetches data from a Blizzard API endpoint
-func (c *BlizzardClient) fetchAPI(url, accessToken string) (map[string]interface{}, error) {
-	req, err := http.NewRequest("GET", url, nil)
+// fetchJSONHeader is like fetchJSON but also returns the response headers
+func (c *BlizzardClient) fetchJSONHeader(ctx context.Context, url, accessToken string, v interface{}) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -151,24 +331,26 @@ func (c *BlizzardClient) fetchAPI(url, accessToken string) (map[string]interface
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, newRequestError(c.GetClientName(), err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The token was revoked or expired early, fetch a fresh one next time
+		c.tokens.Invalidate()
+	}
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to fetch API data: %s (status code: %d)", string(body), resp.StatusCode)
+		return nil, newStatusError(c.GetClientName(), resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, newRequestError(c.GetClientName(), err)
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := json.Unmarshal(body, v); err != nil {
+		return nil, &Error{Kind: ErrUpstream, Client: c.GetClientName(), Err: fmt.Errorf("failed to parse response: %w", err)}
 	}
 
-	return result, nil
+	return resp.Header, nil
 }
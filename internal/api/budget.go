@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBudgetWait is the longest a call waits for the budget before BudgetExhaustedError is returned
+const maxBudgetWait = 2 * time.Second
+
+// RegionBudget is a client-side token bucket per Blizzard region that keeps the app below the
+// upstream rate limits. It is safe for concurrent use and is meant to be shared by all Blizzard API clients.
+type RegionBudget struct {
+	baseURL  string
+	requests int
+	rate     float64
+
+	mu      sync.Mutex
+	buckets map[string]*budgetBucket
+}
+
+// budgetBucket holds the tokens of a region, tokens may be negative when calls are waiting
+type budgetBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRegionBudget creates a budget allowing requests per period for every region, in bursts of up to requests.
+// The base URL is the Blizzard API base URL with the {region} placeholder used to tell regions apart.
+func NewRegionBudget(baseURL string, requests int, period time.Duration) *RegionBudget {
+	return &RegionBudget{
+		baseURL:  baseURL,
+		requests: requests,
+		rate:     float64(requests) / period.Seconds(),
+		buckets:  make(map[string]*budgetBucket),
+	}
+}
+
+// Wait takes a token from the bucket of the region of a URL, waiting for it if needed.
+// It returns a BudgetExhaustedError without waiting if the token is more than maxBudgetWait away.
+func (b *RegionBudget) Wait(ctx context.Context, url string) error {
+	region := b.region(url)
+	wait, err := b.reserve(region)
+	if err != nil || wait <= 0 {
+		return err
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// The call is never made, give the token back so cancelled lookups do not drain the budget
+		b.release(region)
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token from the bucket of a region and returns how long until it is available
+func (b *RegionBudget) reserve(region string) (time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := b.buckets[region]
+	if !ok {
+		bucket = &budgetBucket{tokens: float64(b.requests), updated: now}
+		b.buckets[region] = bucket
+	}
+	bucket.tokens = min(float64(b.requests), bucket.tokens+now.Sub(bucket.updated).Seconds()*b.rate)
+	bucket.updated = now
+
+	wait := time.Duration((1 - bucket.tokens) / b.rate * float64(time.Second))
+	if wait > maxBudgetWait {
+		return 0, &BudgetExhaustedError{Region: region, RetryAfter: wait}
+	}
+	bucket.tokens--
+	return wait, nil
+}
+
+// release returns a reserved token to the bucket of a region
+func (b *RegionBudget) release(region string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if bucket, ok := b.buckets[region]; ok {
+		bucket.tokens = min(float64(b.requests), bucket.tokens+1)
+	}
+}
+
+// region returns the region of a URL by matching it against the {region} placeholder of the base URL
+func (b *RegionBudget) region(url string) string {
+	prefix, _, ok := strings.Cut(b.baseURL, "{region}")
+	if !ok || !strings.HasPrefix(url, prefix) {
+		return ""
+	}
+	rest := url[len(prefix):]
+	if end := strings.IndexAny(rest, "./?"); end >= 0 {
+		rest = rest[:end]
+	}
+	return rest
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"sort"
+)
+
+// Names of the character sub-requests, used to report which parts of a character failed to load
+const (
+	CharacterProfileRequest    = "profile"
+	CharacterMediaRequest      = "character-media"
+	CharacterStatisticsRequest = "statistics"
+	CharacterEquipmentRequest  = "equipment"
+	CharacterMythicRequest     = "mythic-keystone-profile"
+	CharacterMythicSeason      = "mythic-keystone-profile/season"
+	CharacterRaidsRequest      = "encounters/raids"
+	CharacterPvPRequest        = "pvp-summary"
+	CharacterPvPBrackets       = "pvp-bracket"
+)
+
+// CharacterProfile represents the response from the character profile summary endpoint
+type CharacterProfile struct {
+	ID                int            `json:"id"`
+	Name              string         `json:"name"`
+	Level             int            `json:"level"`
+	AverageItemLevel  int            `json:"average_item_level"`
+	EquippedItemLevel int            `json:"equipped_item_level"`
+	AchievementPoints int            `json:"achievement_points"`
+	Realm             NamedReference `json:"realm"`
+	Faction           TypedName      `json:"faction"`
+	Race              NamedReference `json:"race"`
+	CharacterClass    NamedReference `json:"character_class"`
+	ActiveSpec        NamedReference `json:"active_spec"`
+	Guild             NamedReference `json:"guild"`
+}
+
+// CharacterMedia represents the response from the character media endpoint
+type CharacterMedia struct {
+	Assets []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"assets"`
+}
+
+// GetMainRawImage returns the main-raw image URL from the character media
+func (media *CharacterMedia) GetMainRawImage() string {
+	for _, asset := range media.Assets {
+		if asset.Key == "main-raw" {
+			return asset.Value
+		}
+	}
+	return ""
+}
+
+// CharacterStatistics represents the response from the character statistics endpoint
+type CharacterStatistics struct {
+	Health    int            `json:"health"`
+	Power     int            `json:"power"`
+	PowerType NamedReference `json:"power_type"`
+	Stamina   struct {
+		Base      int `json:"base"`
+		Effective int `json:"effective"`
+	} `json:"stamina"`
+}
+
+// CharacterResponse aggregates every Blizzard endpoint that makes up a character page.
+// Only the profile is required, the other sections are nil when their request failed.
+type CharacterResponse struct {
+	Profile        *CharacterProfile
+	Media          *CharacterMedia
+	Statistics     *CharacterStatistics
+	Equipment      *EquipmentResponse
+	MythicKeystone *MythicKeystoneResponse
+	Raids          *RaidEncountersResponse
+	PvP            *PvPResponse
+
+	// LastModified is the Last-Modified header of the profile summary, used to revalidate cached characters
+	LastModified string
+
+	// Errors holds the error of every sub-request that failed, keyed by request name
+	Errors map[string]error `json:"-"`
+}
+
+// FailedRequests returns the names of the sub-requests that failed, sorted by name. Resources Blizzard
+// does not know are not failures, characters that never ran a keystone or fought in PvP have none.
+func (r *CharacterResponse) FailedRequests() []string {
+	failed := make([]string, 0, len(r.Errors))
+	for name, err := range r.Errors {
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		failed = append(failed, name)
+	}
+	sort.Strings(failed)
+	return failed
+}
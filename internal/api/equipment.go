@@ -0,0 +1,69 @@
+package api
+
+// TypedName represents a Blizzard API enum value with its localized name
+type TypedName struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// NamedReference represents a reference to another Blizzard API resource
+type NamedReference struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// ItemLevel represents the item level of an equipped item
+type ItemLevel struct {
+	Value         int    `json:"value"`
+	DisplayString string `json:"display_string"`
+}
+
+// ItemEnchantment represents an enchantment applied to an equipped item
+type ItemEnchantment struct {
+	DisplayString string         `json:"display_string"`
+	EnchantmentID int            `json:"enchantment_id"`
+	SourceItem    NamedReference `json:"source_item"`
+}
+
+// ItemSocket represents a socket on an equipped item and the gem inside it
+type ItemSocket struct {
+	SocketType    TypedName      `json:"socket_type"`
+	Item          NamedReference `json:"item"`
+	DisplayString string         `json:"display_string"`
+}
+
+// ItemSetEffect represents a set bonus of an item set
+type ItemSetEffect struct {
+	DisplayString string `json:"display_string"`
+	RequiredCount int    `json:"required_count"`
+	IsActive      bool   `json:"is_active"`
+}
+
+// ItemSet represents an item set and the bonuses granted by it
+type ItemSet struct {
+	ItemSet NamedReference `json:"item_set"`
+	Items   []struct {
+		Item       NamedReference `json:"item"`
+		IsEquipped bool           `json:"is_equipped"`
+	} `json:"items"`
+	Effects       []ItemSetEffect `json:"effects"`
+	DisplayString string          `json:"display_string"`
+}
+
+// EquippedItem represents a single item equipped by a character
+type EquippedItem struct {
+	Item         NamedReference    `json:"item"`
+	Slot         TypedName         `json:"slot"`
+	Quality      TypedName         `json:"quality"`
+	Name         string            `json:"name"`
+	Level        ItemLevel         `json:"level"`
+	Enchantments []ItemEnchantment `json:"enchantments"`
+	Sockets      []ItemSocket      `json:"sockets"`
+	Set          *ItemSet          `json:"set"`
+}
+
+// EquipmentResponse represents the response from the character equipment endpoint
+type EquipmentResponse struct {
+	EquippedItems    []EquippedItem `json:"equipped_items"`
+	EquippedItemSets []ItemSet      `json:"equipped_item_sets"`
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Kinds of upstream failures, every error returned by the API clients matches one of them with errors.Is
+var (
+	// ErrNotFound is returned when the upstream API does not know the requested resource
+	ErrNotFound = errors.New("not found")
+
+	// ErrUnauthorized is returned when the upstream API rejects our credentials or access token
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrRateLimited is returned when the upstream API or our own request budget limits the calls
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrUpstream is returned when the upstream API fails or returns an invalid response
+	ErrUpstream = errors.New("upstream error")
+
+	// ErrTimeout is returned when the upstream API does not answer in time
+	ErrTimeout = errors.New("timeout")
+)
+
+// ErrBudgetExhausted is matched by BudgetExhaustedError with errors.Is
+var ErrBudgetExhausted = errors.New("request budget exhausted")
+
+// maxErrorBody is how much of an error response body is kept in the error message
+const maxErrorBody = 512
+
+// Error is a failed upstream API call
+type Error struct {
+	// Kind is one of the sentinel errors such as ErrNotFound
+	Kind error
+	// Client is the name of the API client
+	Client string
+	// StatusCode is the HTTP status code of the response, 0 if there was none
+	StatusCode int
+	// Message is the error response body or GraphQL error message
+	Message string
+	// RetryAfter is the Retry-After of the response, 0 if it had none
+	RetryAfter time.Duration
+	// Err is the underlying error when there was no response
+	Err error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s: %s", e.Client, e.Kind)
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(" (status code: %d)", e.StatusCode)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the kind and the underlying error so both match with errors.Is
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// newStatusError creates the error of an unsuccessful response, keeping the start of its body
+func newStatusError(client string, resp *http.Response) *Error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	retryAfter, _ := parseRetryAfter(resp.Header.Get("Retry-After"))
+
+	return &Error{
+		Kind:       statusKind(resp.StatusCode),
+		Client:     client,
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+		RetryAfter: retryAfter,
+	}
+}
+
+// newRequestError creates the error of a call that got no response
+func newRequestError(client string, err error) error {
+	// Budget errors already carry their kind
+	if errors.Is(err, ErrBudgetExhausted) {
+		return err
+	}
+
+	kind := ErrUpstream
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		kind = ErrTimeout
+	}
+	return &Error{Kind: kind, Client: client, Err: err}
+}
+
+// statusKind returns the kind of failure of an HTTP status code
+func statusKind(status int) error {
+	switch status {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return ErrTimeout
+	}
+	return ErrUpstream
+}
+
+// BudgetExhaustedError is returned instead of calling the upstream API when the requests per
+// second budget of a region would not allow the call within the maximum wait
+type BudgetExhaustedError struct {
+	Region     string
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *BudgetExhaustedError) Error() string {
+	return fmt.Sprintf("%s for region %q, retry after %s", ErrBudgetExhausted, e.Region, e.RetryAfter)
+}
+
+// Is reports whether target is ErrBudgetExhausted or ErrRateLimited
+func (e *BudgetExhaustedError) Is(target error) bool {
+	return target == ErrBudgetExhausted || target == ErrRateLimited
+}
@@ -0,0 +1,30 @@
+package api
+
+// RosterCharacter represents a character of the guild roster. The roster only references the class
+// and race by ID and the faction by type, without their names.
+type RosterCharacter struct {
+	ID            int            `json:"id"`
+	Name          string         `json:"name"`
+	Level         int            `json:"level"`
+	Realm         NamedReference `json:"realm"`
+	PlayableClass NamedReference `json:"playable_class"`
+	PlayableRace  NamedReference `json:"playable_race"`
+	Faction       TypedName      `json:"faction"`
+}
+
+// RosterMember represents a member of the guild roster, rank 0 is the guild master
+type RosterMember struct {
+	Character RosterCharacter `json:"character"`
+	Rank      int             `json:"rank"`
+}
+
+// GuildRosterResponse represents the response from the guild roster endpoint
+type GuildRosterResponse struct {
+	Guild struct {
+		ID      int            `json:"id"`
+		Name    string         `json:"name"`
+		Realm   NamedReference `json:"realm"`
+		Faction TypedName      `json:"faction"`
+	} `json:"guild"`
+	Members []RosterMember `json:"members"`
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"time"
+	"wowarmory/internal/logging"
+	"wowarmory/internal/metrics"
+)
+
+// responseHeaderTimeout is how long an upstream API may take to start responding before the attempt is retried
+const responseHeaderTimeout = 10 * time.Second
+
+// upstreamTransport is the transport shared by all API clients
+var upstreamTransport = func() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = responseHeaderTimeout
+	return transport
+}()
+
+// httpClientOptions configures the HTTP client of an API client
+type httpClientOptions struct {
+	// budget limits the calls per region, nil for no limit
+	budget *RegionBudget
+	// retryPOST also retries POST requests, for APIs where they are read-only queries such as GraphQL
+	retryPOST bool
+	// statusErrors turns unsuccessful responses into errors, for libraries that ignore the status code
+	statusErrors bool
+}
+
+// newHTTPClient creates the HTTP client of an API client, retrying failed calls and logging and
+// measuring every attempt
+func newHTTPClient(client string, options httpClientOptions) *http.Client {
+	var transport http.RoundTripper = &retryTransport{
+		base: &logging.Transport{
+			Client: client,
+			Base:   &metrics.Transport{Client: client, Base: upstreamTransport},
+		},
+		budget:    options.budget,
+		retryPOST: options.retryPOST,
+	}
+	if options.statusErrors {
+		transport = &statusTransport{client: client, base: transport}
+	}
+	return &http.Client{Transport: transport}
+}
+
+// statusTransport is an http.RoundTripper that returns an Error for every unsuccessful response
+type statusTransport struct {
+	client string
+	base   http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (t *statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode < http.StatusBadRequest {
+		return resp, err
+	}
+	defer resp.Body.Close()
+	return nil, newStatusError(t.client, resp)
+}
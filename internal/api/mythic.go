@@ -0,0 +1,66 @@
+package api
+
+// RGBAColor represents a color as returned by the Blizzard API
+type RGBAColor struct {
+	R int     `json:"r"`
+	G int     `json:"g"`
+	B int     `json:"b"`
+	A float64 `json:"a"`
+}
+
+// MythicRating represents a Mythic+ rating and the color it is displayed with
+type MythicRating struct {
+	Color  RGBAColor `json:"color"`
+	Rating float64   `json:"rating"`
+}
+
+// MythicKeystoneRun represents a single Mythic+ run
+type MythicKeystoneRun struct {
+	CompletedTimestamp    int64            `json:"completed_timestamp"`
+	Duration              int64            `json:"duration"`
+	KeystoneLevel         int              `json:"keystone_level"`
+	KeystoneAffixes       []NamedReference `json:"keystone_affixes"`
+	Dungeon               NamedReference   `json:"dungeon"`
+	IsCompletedWithinTime bool             `json:"is_completed_within_time"`
+	MythicRating          MythicRating     `json:"mythic_rating"`
+}
+
+// MythicKeystoneProfile represents the response from the mythic keystone profile endpoint
+type MythicKeystoneProfile struct {
+	Seasons []struct {
+		ID int `json:"id"`
+	} `json:"seasons"`
+	CurrentMythicRating MythicRating `json:"current_mythic_rating"`
+}
+
+// MythicKeystoneSeason represents the response from the mythic keystone season endpoint
+type MythicKeystoneSeason struct {
+	Season struct {
+		ID int `json:"id"`
+	} `json:"season"`
+	BestRuns     []MythicKeystoneRun `json:"best_runs"`
+	MythicRating MythicRating        `json:"mythic_rating"`
+}
+
+// MythicKeystoneResponse combines the mythic keystone profile with the current season details
+type MythicKeystoneResponse struct {
+	Profile MythicKeystoneProfile
+	Season  *MythicKeystoneSeason
+}
+
+// PlayedSeason reports whether the character played in the given season
+func (p *MythicKeystoneProfile) PlayedSeason(id int) bool {
+	for _, season := range p.Seasons {
+		if season.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// MythicKeystoneSeasonIndex represents the response from the mythic keystone season index endpoint
+type MythicKeystoneSeasonIndex struct {
+	CurrentSeason struct {
+		ID int `json:"id"`
+	} `json:"current_season"`
+}
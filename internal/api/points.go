@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+	"wowarmory/internal/metrics"
+)
+
+// RateLimitData is the Warcraftlogs points budget of the current hour, requested along with every query
+type RateLimitData struct {
+	LimitPerHour        int     `json:"limitPerHour"`
+	PointsSpentThisHour float64 `json:"pointsSpentThisHour"`
+	// PointsResetIn is how many seconds are left until the points spent are reset
+	PointsResetIn int `json:"pointsResetIn"`
+}
+
+// PointsStatus is the Warcraftlogs points budget as last reported by the API
+type PointsStatus struct {
+	// Known is false until a query has reported the budget
+	Known        bool      `json:"known"`
+	LimitPerHour int       `json:"limit_per_hour"`
+	Spent        float64   `json:"spent"`
+	Remaining    float64   `json:"remaining"`
+	Floor        int       `json:"floor"`
+	ResetAt      time.Time `json:"reset_at"`
+	// Low is true when the remaining points are below the floor, expensive queries are then refused or cut short
+	Low bool `json:"low"`
+}
+
+// pointsTracker keeps the last points budget reported by Warcraftlogs, it is safe for concurrent use
+type pointsTracker struct {
+	floor int
+
+	mu      sync.Mutex
+	data    RateLimitData
+	resetAt time.Time
+	known   bool
+}
+
+// update records the points budget of a query response
+func (t *pointsTracker) update(data RateLimitData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.data = data
+	t.resetAt = time.Now().Add(time.Duration(data.PointsResetIn) * time.Second)
+	t.known = true
+	metrics.ObserveWarcraftlogsPoints(data.LimitPerHour, data.PointsSpentThisHour)
+}
+
+// status returns the points budget, the points spent are known to be reset once the hour is over
+func (t *pointsTracker) status() PointsStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := PointsStatus{Floor: t.floor}
+	if !t.known {
+		return status
+	}
+
+	status.Known = true
+	status.LimitPerHour = t.data.LimitPerHour
+	status.ResetAt = t.resetAt
+	if time.Now().Before(t.resetAt) {
+		status.Spent = t.data.PointsSpentThisHour
+	}
+	status.Remaining = max(0, float64(status.LimitPerHour)-status.Spent)
+	status.Low = status.Remaining < float64(t.floor)
+	return status
+}
+
+// pointsResponse decodes the rateLimitData of a query next to the query response
+type pointsResponse struct {
+	response      interface{}
+	rateLimitData *RateLimitData
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (r *pointsResponse) UnmarshalJSON(data []byte) error {
+	var points struct {
+		RateLimitData *RateLimitData `json:"rateLimitData"`
+	}
+	if err := json.Unmarshal(data, &points); err != nil {
+		return err
+	}
+	r.rateLimitData = points.RateLimitData
+	return json.Unmarshal(data, r.response)
+}
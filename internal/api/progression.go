@@ -0,0 +1,86 @@
+package api
+
+import "time"
+
+// Zone represents a Warcraftlogs zone with its difficulties and encounters
+type Zone struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Difficulties []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"difficulties"`
+	Encounters []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"encounters"`
+}
+
+// HasDifficulty reports whether the zone can be played on the difficulty
+func (z Zone) HasDifficulty(difficulty int) bool {
+	for _, d := range z.Difficulties {
+		if d.ID == difficulty {
+			return true
+		}
+	}
+	return false
+}
+
+// ZonesResponse represents the response from the Warcraftlogs API for a zones query
+type ZonesResponse struct {
+	WorldData struct {
+		Expansions []struct {
+			ID    int    `json:"id"`
+			Name  string `json:"name"`
+			Zones []Zone `json:"zones"`
+		} `json:"expansions"`
+	} `json:"worldData"`
+}
+
+// ProgressionFight represents a single boss pull in a report
+type ProgressionFight struct {
+	EncounterID int  `json:"encounterID"`
+	Kill        bool `json:"kill"`
+	// FightPercentage is the boss health left at the end of the pull, counting earlier phases
+	FightPercentage float64 `json:"fightPercentage"`
+	// StartTime is the start of the pull in milliseconds since the start of the report
+	StartTime int64 `json:"startTime"`
+}
+
+// ProgressionReport represents the boss pulls of a single report
+type ProgressionReport struct {
+	Code string `json:"code"`
+	// StartTime is the start of the report in milliseconds since the Unix epoch
+	StartTime int64              `json:"startTime"`
+	Fights    []ProgressionFight `json:"fights"`
+}
+
+// FightTime returns the start time of a pull of the report
+func (r ProgressionReport) FightTime(fight ProgressionFight) time.Time {
+	return time.UnixMilli(r.StartTime + fight.StartTime)
+}
+
+// ProgressionReports represents a page of the reports of a guild in a zone, most recent first
+type ProgressionReports struct {
+	Data         []ProgressionReport `json:"data"`
+	HasMorePages bool                `json:"has_more_pages"`
+}
+
+// GuildProgressionResponse represents the progression of a guild in a zone on a single difficulty.
+// Zones lists the raid zones of the current expansion to choose from.
+type GuildProgressionResponse struct {
+	Zones      []Zone `json:"zones"`
+	Zone       Zone   `json:"zone"`
+	Difficulty int    `json:"difficulty"`
+	GuildData  struct {
+		Guild *struct {
+			Name        string           `json:"name"`
+			ZoneRanking GuildZoneRanking `json:"zoneRanking"`
+		} `json:"guild"`
+	} `json:"guildData"`
+	ReportData struct {
+		Reports ProgressionReports `json:"reports"`
+	} `json:"reportData"`
+	// Partial is true when the reports stopped paging before the oldest, so first kills and pulls may be missing
+	Partial bool `json:"partial,omitempty"`
+}
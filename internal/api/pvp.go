@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/url"
+	"path"
+)
+
+// PvPMatchStatistics represents the match statistics of a PvP bracket
+type PvPMatchStatistics struct {
+	Played int `json:"played"`
+	Won    int `json:"won"`
+	Lost   int `json:"lost"`
+}
+
+// PvPSummary represents the response from the character PvP summary endpoint
+type PvPSummary struct {
+	HonorLevel     int `json:"honor_level"`
+	HonorableKills int `json:"honorable_kills"`
+	Brackets       []struct {
+		Href string `json:"href"`
+	} `json:"brackets"`
+}
+
+// PvPBracket represents the response from the character PvP bracket endpoint
+type PvPBracket struct {
+	Slug    string `json:"slug"`
+	Bracket struct {
+		ID   int    `json:"id"`
+		Type string `json:"type"`
+	} `json:"bracket"`
+	Season struct {
+		ID int `json:"id"`
+	} `json:"season"`
+	Specialization        NamedReference     `json:"specialization"`
+	Rating                int                `json:"rating"`
+	SeasonMatchStatistics PvPMatchStatistics `json:"season_match_statistics"`
+	WeeklyMatchStatistics PvPMatchStatistics `json:"weekly_match_statistics"`
+}
+
+// PvPResponse combines the PvP summary with the character's bracket statistics
+type PvPResponse struct {
+	Summary  PvPSummary
+	Brackets []PvPBracket
+}
+
+// BracketSlugs returns the bracket names, such as 3v3 or shuffle-mage-frost, linked from the summary
+func (s *PvPSummary) BracketSlugs() []string {
+	slugs := make([]string, 0, len(s.Brackets))
+	for _, bracket := range s.Brackets {
+		u, err := url.Parse(bracket.Href)
+		if err != nil || u.Path == "" {
+			continue
+		}
+		slugs = append(slugs, path.Base(u.Path))
+	}
+	return slugs
+}
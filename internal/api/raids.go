@@ -0,0 +1,48 @@
+package api
+
+// RaidEncounterProgress represents the kills of a single raid encounter on a difficulty
+type RaidEncounterProgress struct {
+	Encounter         NamedReference `json:"encounter"`
+	CompletedCount    int            `json:"completed_count"`
+	LastKillTimestamp int64          `json:"last_kill_timestamp"`
+}
+
+// RaidMode represents the progress of a character in a raid instance on a difficulty
+type RaidMode struct {
+	Difficulty TypedName `json:"difficulty"`
+	Status     TypedName `json:"status"`
+	Progress   struct {
+		CompletedCount int                     `json:"completed_count"`
+		TotalCount     int                     `json:"total_count"`
+		Encounters     []RaidEncounterProgress `json:"encounters"`
+	} `json:"progress"`
+}
+
+// RaidInstance represents the progress of a character in a raid instance
+type RaidInstance struct {
+	Instance NamedReference `json:"instance"`
+	Modes    []RaidMode     `json:"modes"`
+}
+
+// RaidExpansion represents the raid instances of an expansion
+type RaidExpansion struct {
+	Expansion NamedReference `json:"expansion"`
+	Instances []RaidInstance `json:"instances"`
+}
+
+// RaidEncountersResponse represents the response from the character raid encounters endpoint
+type RaidEncountersResponse struct {
+	Expansions []RaidExpansion `json:"expansions"`
+}
+
+// LatestExpansion returns the most recent expansion the character raided in, which is not the current
+// expansion for characters that did not raid in it yet
+func (r *RaidEncountersResponse) LatestExpansion() *RaidExpansion {
+	var current *RaidExpansion
+	for i := range r.Expansions {
+		if current == nil || r.Expansions[i].Expansion.ID > current.Expansion.ID {
+			current = &r.Expansions[i]
+		}
+	}
+	return current
+}
@@ -0,0 +1,49 @@
+package api
+
+// Warcraftlogs raid difficulty IDs
+const (
+	DifficultyNormal = 3
+	DifficultyHeroic = 4
+	DifficultyMythic = 5
+)
+
+// CharacterRankingsResponse represents the response from the Warcraftlogs API for a character rankings query
+type CharacterRankingsResponse struct {
+	CharacterData struct {
+		Character *CharacterRankings `json:"character"`
+	} `json:"characterData"`
+}
+
+// CharacterRankings represents the rankings of a character in the current raid zone on each difficulty
+type CharacterRankings struct {
+	Name    string       `json:"name"`
+	ClassID int          `json:"classID"`
+	Normal  ZoneRankings `json:"normal"`
+	Heroic  ZoneRankings `json:"heroic"`
+	Mythic  ZoneRankings `json:"mythic"`
+}
+
+// ZoneRankings represents the rankings of a character in a raid zone on a single difficulty.
+// The averages are nil when the character has no ranked kills.
+type ZoneRankings struct {
+	Zone                     int                `json:"zone"`
+	Difficulty               int                `json:"difficulty"`
+	Metric                   string             `json:"metric"`
+	BestPerformanceAverage   *float64           `json:"bestPerformanceAverage"`
+	MedianPerformanceAverage *float64           `json:"medianPerformanceAverage"`
+	Rankings                 []EncounterRanking `json:"rankings"`
+}
+
+// EncounterRanking represents the best and median parse of a character on a single encounter.
+// The percentiles are nil when the character has not killed the encounter.
+type EncounterRanking struct {
+	Encounter struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"encounter"`
+	RankPercent   *float64 `json:"rankPercent"`
+	MedianPercent *float64 `json:"medianPercent"`
+	TotalKills    int      `json:"totalKills"`
+	Spec          string   `json:"spec"`
+	BestAmount    float64  `json:"bestAmount"`
+}
@@ -0,0 +1,131 @@
+package api
+
+// ReportZone represents the raid zone a report was logged in
+type ReportZone struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// ReportOwner represents the user who uploaded a report
+type ReportOwner struct {
+	Name string `json:"name"`
+}
+
+// ReportSummary represents a report in the report list of a guild.
+// StartTime and EndTime are in milliseconds since the Unix epoch.
+type ReportSummary struct {
+	Code      string       `json:"code"`
+	Title     string       `json:"title"`
+	StartTime int64        `json:"startTime"`
+	EndTime   int64        `json:"endTime"`
+	Zone      *ReportZone  `json:"zone"`
+	Owner     *ReportOwner `json:"owner"`
+}
+
+// ReportPage represents a page of the reports of a guild, most recent first
+type ReportPage struct {
+	Data         []ReportSummary `json:"data"`
+	Total        int             `json:"total"`
+	PerPage      int             `json:"per_page"`
+	CurrentPage  int             `json:"current_page"`
+	LastPage     int             `json:"last_page"`
+	HasMorePages bool            `json:"has_more_pages"`
+}
+
+// GuildReportsResponse represents the response from the Warcraftlogs API for a guild reports query
+type GuildReportsResponse struct {
+	GuildData struct {
+		Guild *struct {
+			Name string `json:"name"`
+		} `json:"guild"`
+	} `json:"guildData"`
+	ReportData struct {
+		Reports ReportPage `json:"reports"`
+	} `json:"reportData"`
+}
+
+// ReportFight represents a boss pull in a report.
+// StartTime and EndTime are in milliseconds since the start of the report.
+type ReportFight struct {
+	ID          int    `json:"id"`
+	EncounterID int    `json:"encounterID"`
+	Name        string `json:"name"`
+	Difficulty  int    `json:"difficulty"`
+	Kill        bool   `json:"kill"`
+	// BossPercentage is the health left on the boss at the end of the pull
+	BossPercentage float64 `json:"bossPercentage"`
+	// FightPercentage is the health left at the end of the pull, counting earlier phases
+	FightPercentage float64 `json:"fightPercentage"`
+	StartTime       int64   `json:"startTime"`
+	EndTime         int64   `json:"endTime"`
+}
+
+// Report represents a report with its boss pulls
+type Report struct {
+	ReportSummary
+	Guild *struct {
+		Name   string `json:"name"`
+		Server struct {
+			Slug   string `json:"slug"`
+			Region struct {
+				Slug string `json:"slug"`
+			} `json:"region"`
+		} `json:"server"`
+	} `json:"guild"`
+	Fights []ReportFight `json:"fights"`
+}
+
+// ReportResponse represents the response from the Warcraftlogs API for a report query
+type ReportResponse struct {
+	ReportData struct {
+		Report *Report `json:"report"`
+	} `json:"reportData"`
+}
+
+// Data types of report tables
+const (
+	TableDamageDone  = "DamageDone"
+	TableHealing     = "Healing"
+	TableDamageTaken = "DamageTaken"
+	TableDeaths      = "Deaths"
+)
+
+// TableDataTypes are the report table data types that can be requested, in display order
+var TableDataTypes = []string{TableDamageDone, TableHealing, TableDamageTaken, TableDeaths}
+
+// TableEntry represents a player in a report table. Deaths tables have an entry per death with the
+// time of death in milliseconds since the start of the report, the other tables have the total.
+type TableEntry struct {
+	Name string `json:"name"`
+	ID   int    `json:"id"`
+	// Type is the class of the player and Icon is the class and spec, such as Shaman-Elemental
+	Type        string  `json:"type"`
+	Icon        string  `json:"icon"`
+	Total       float64 `json:"total"`
+	Timestamp   int64   `json:"timestamp"`
+	KillingBlow *struct {
+		Name string `json:"name"`
+	} `json:"killingBlow"`
+}
+
+// ReportTable represents a report table of a single fight
+type ReportTable struct {
+	Data struct {
+		// TotalTime is the duration of the fight in milliseconds
+		TotalTime int64        `json:"totalTime"`
+		Entries   []TableEntry `json:"entries"`
+	} `json:"data"`
+}
+
+// ReportTableResponse represents the response from the Warcraftlogs API for a report table query
+type ReportTableResponse struct {
+	ReportData struct {
+		Report *struct {
+			Code   string        `json:"code"`
+			Title  string        `json:"title"`
+			Fights []ReportFight `json:"fights"`
+			Table  ReportTable   `json:"table"`
+		} `json:"report"`
+	} `json:"reportData"`
+	DataType string `json:"dataType"`
+}
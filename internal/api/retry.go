@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxRetries is how often a failed upstream call is retried
+	maxRetries = 2
+
+	// retryBaseDelay is the backoff before the first retry, it doubles with every retry
+	retryBaseDelay = 250 * time.Millisecond
+
+	// retryMaxDelay caps the backoff between retries
+	retryMaxDelay = 2 * time.Second
+
+	// maxRetryAfter is the longest Retry-After that is waited for, longer ones are returned to the caller
+	maxRetryAfter = 5 * time.Second
+)
+
+// retryTransport is an http.RoundTripper that retries idempotent upstream calls failing with
+// 429, 5xx or a timeout, using jittered exponential backoff or the Retry-After of the response.
+// With a budget every attempt first takes a token from the budget of its region.
+type retryTransport struct {
+	base      http.RoundTripper
+	budget    *RegionBudget
+	retryPOST bool
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	retryable := req.Method == http.MethodGet || req.Method == http.MethodHead ||
+		t.retryPOST && req.Method == http.MethodPost && req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if t.budget != nil {
+			if err := t.budget.Wait(req.Context(), req.URL.String()); err != nil {
+				return nil, err
+			}
+		}
+
+		attemptReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if !retryable || attempt == maxRetries || !shouldRetry(req.Context(), resp, err) {
+			return resp, err
+		}
+
+		delay := backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				if retryAfter > maxRetryAfter {
+					return resp, nil
+				}
+				delay = retryAfter
+			}
+			// Drain the body so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// shouldRetry reports whether a failed attempt may succeed when retried
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the jittered delay before a retry, between half and all of the exponential backoff
+func backoff(attempt int) time.Duration {
+	delay := min(retryBaseDelay<<attempt, retryMaxDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
@@ -1,18 +1,18 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 	"wowarmory/internal/interfaces"
 )
 
 type TokenClient struct {
-	clientID     string
-	clientSecret string
-	httpClient   *http.Client
+	baseURL    string
+	tokens     *AccessTokenProvider
+	httpClient *http.Client
 }
 
 var _ interfaces.TokenAPI = (*TokenClient)(nil)
@@ -22,64 +22,31 @@ func (c *TokenClient) GetClientName() string {
 	return "TokenAPI"
 }
 
-// NewTokenClient creates a new Token API client
-func NewTokenClient(clientID, clientSecret string) *TokenClient {
-	return &TokenClient{
-		clientID:     clientID,
-		clientSecret: clientSecret,
-		httpClient:   &http.Client{},
+// NewTokenClient creates a new Token API client using the shared token provider and region budget,
+// a nil budget does not limit the calls. The base URL may contain a {region} placeholder, e.g.
+// https://{region}.api.blizzard.com.
+func NewTokenClient(baseURL string, tokens *AccessTokenProvider, budget *RegionBudget) *TokenClient {
+	c := &TokenClient{
+		baseURL: baseURL,
+		tokens:  tokens,
 	}
+	c.httpClient = newHTTPClient(c.GetClientName(), httpClientOptions{budget: budget})
+	return c
 }
 
-// GetAccessToken gets an access token from the Token API
-func (c *TokenClient) GetAccessToken() (string, error) {
-	if c.clientID == "" || c.clientSecret == "" {
-		return "", fmt.Errorf("missing client ID or client secret")
-	}
-
-	url := "https://oauth.battle.net/oauth/token"
-	req, err := http.NewRequest("POST", url, strings.NewReader("grant_type=client_credentials"))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-	req.SetBasicAuth(c.clientID, c.clientSecret)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("failed to get access token: %s (status code: %d)", string(body), resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	accessToken, ok := result["access_token"].(string)
-	if !ok {
-		return "", fmt.Errorf("unable to get access token from response")
-	}
-	return accessToken, nil
+// GetAccessToken returns a cached access token, refreshing it if needed
+func (c *TokenClient) GetAccessToken(ctx context.Context) (string, error) {
+	return c.tokens.Token(ctx)
 }
 
-func (c *TokenClient) GetTokenPrice(accessToken, region string) (float64, error) {
-	if accessToken == "" {
-		return 0, fmt.Errorf("missing access token")
+func (c *TokenClient) GetTokenPrice(ctx context.Context, region string) (float64, error) {
+	accessToken, err := c.tokens.Token(ctx)
+	if err != nil {
+		return 0, err
 	}
 
-	url := fmt.Sprintf("https://%s.api.blizzard.com/data/wow/token/index?namespace=dynamic-%s&locale=en_US", region, region)
-	req, err := http.NewRequest("GET", url, nil)
+	url := fmt.Sprintf("%s/data/wow/token/index?namespace=dynamic-%s&locale=en_US", regionURL(c.baseURL, region), region)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -87,18 +54,21 @@ func (c *TokenClient) GetTokenPrice(accessToken, region string) (float64, error)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("failed to send request: %w", err)
+		return 0, newRequestError(c.GetClientName(), err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The token was revoked or expired early, fetch a fresh one next time
+		c.tokens.Invalidate()
+	}
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("failed to get token price: %s (status code: %d)", string(body), resp.StatusCode)
+		return 0, newStatusError(c.GetClientName(), resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return 0, fmt.Errorf("failed to read response body: %w", err)
+		return 0, newRequestError(c.GetClientName(), err)
 	}
 
 	var result map[string]interface{}
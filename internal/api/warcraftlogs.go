@@ -2,8 +2,18 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"wowarmory/internal/config"
 	"wowarmory/internal/interfaces"
+	"wowarmory/internal/logging"
+	"wowarmory/internal/metrics"
 
 	"github.com/machinebox/graphql"
 )
@@ -11,9 +21,20 @@ import (
 // WarcraftlogsClient is a client for the Warcraftlogs API
 type WarcraftlogsClient struct {
 	client      *graphql.Client
-	accessToken string
+	tokens      *AccessTokenProvider
+	staticToken string
+	config      config.WarcraftlogsConfig
+	points      *pointsTracker
+
+	mu sync.Mutex
+	// staticUntil is when the token endpoint is tried again after it failed, the static token is used until then
+	staticUntil time.Time
 }
 
+// tokenFailureBackoff is how long the static token is used after the token endpoint failed,
+// so a misconfigured or unavailable endpoint does not slow down every query
+const tokenFailureBackoff = time.Minute
+
 // Ensure WarcraftlogsClient implements WarcraftLogsAPI interface
 var _ interfaces.WarcraftLogsAPI = (*WarcraftlogsClient)(nil)
 
@@ -22,30 +43,61 @@ func (c *WarcraftlogsClient) GetClientName() string {
 	return "WarcraftLogsAPI"
 }
 
-// NewWarcraftlogsClient creates a new Warcraftlogs API client
-func NewWarcraftlogsClient(accessToken string) *WarcraftlogsClient {
-	client := graphql.NewClient("https://www.warcraftlogs.com/api/v2/client")
-
-	return &WarcraftlogsClient{
-		client:      client,
-		accessToken: accessToken,
+// NewWarcraftlogsClient creates a new Warcraftlogs API client for the given GraphQL endpoint that
+// authenticates with tokens from the OAuth token provider. The static token is used when tokens is nil
+// or the token endpoint fails, either may be left empty but not both.
+func NewWarcraftlogsClient(apiURL string, tokens *AccessTokenProvider, staticToken string, cfg config.WarcraftlogsConfig) *WarcraftlogsClient {
+	c := &WarcraftlogsClient{
+		tokens:      tokens,
+		staticToken: staticToken,
+		config:      cfg,
+		points:      &pointsTracker{floor: cfg.PointsFloor},
 	}
+	c.client = graphql.NewClient(apiURL, graphql.WithHTTPClient(newHTTPClient(c.GetClientName(), httpClientOptions{retryPOST: true, statusErrors: true})))
+	return c
 }
 
+// attendancePageSize is how many raids are requested per attendance page, the most Warcraftlogs allows
+const attendancePageSize = 25
+
+// maxAttendancePages bounds how many attendance pages a guild lookup requests, whatever the window
+const maxAttendancePages = 10
+
+// Presence of a player in a raid
+const (
+	PresencePresent = 1
+	PresenceBenched = 2
+)
+
 // GuildMember represents a member of a guild
 type GuildMember struct {
 	Name string `json:"name"`
 	Type string `json:"type"`
+	// Presence is PresencePresent or PresenceBenched
+	Presence int `json:"presence"`
 }
 
-// AttendanceEntry represents a single attendance entry
+// AttendanceEntry represents the players of a single raid
 type AttendanceEntry struct {
+	Code string `json:"code"`
+	// StartTime is the start of the raid in milliseconds since the Unix epoch
+	StartTime int64 `json:"startTime"`
+	Zone      *struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"zone"`
 	Players []GuildMember `json:"players"`
 }
 
-// GuildAttendance represents attendance data for a guild
+// Time returns the start time of the raid
+func (e AttendanceEntry) Time() time.Time {
+	return time.UnixMilli(e.StartTime)
+}
+
+// GuildAttendance represents a page of the raids of a guild, most recent first
 type GuildAttendance struct {
-	Data []AttendanceEntry `json:"data"`
+	Data         []AttendanceEntry `json:"data"`
+	HasMorePages bool              `json:"has_more_pages"`
 }
 
 // GuildMembers represents the total number of members in a guild
@@ -84,6 +136,8 @@ type GuildResponse struct {
 	GuildData struct {
 		Guild GuildData `json:"guild"`
 	} `json:"guildData"`
+	// Partial is true when the attendance stopped paging before the end of the attendance window
+	Partial bool `json:"partial,omitempty"`
 }
 
 // GetGuild gets information about a guild from the Warcraftlogs API
@@ -94,18 +148,27 @@ func (c *WarcraftlogsClient) GetGuild(ctx context.Context, name, serverSlug, ser
 			$name: String!
 			$serverSlug: String!
 			$serverRegion: String!
+			$limit: Int!
 		) 
 		{
 			guildData {
 				guild(name: $name, serverSlug: $serverSlug, serverRegion: $serverRegion) {
 					name
-					attendance{
+					attendance(limit: $limit, page: 1){
 						data{
+							code
+							startTime
+							zone{
+								id
+								name
+							}
 							players{
 								name
 								type
+								presence
 							}
 						}
+						has_more_pages
 					}
 					members{
 						total
@@ -128,6 +191,11 @@ func (c *WarcraftlogsClient) GetGuild(ctx context.Context, name, serverSlug, ser
 					}
 				}
 			}
+			rateLimitData {
+				limitPerHour
+				pointsSpentThisHour
+				pointsResetIn
+			}
 		}
 	`)
 
@@ -135,15 +203,713 @@ func (c *WarcraftlogsClient) GetGuild(ctx context.Context, name, serverSlug, ser
 	req.Var("name", name)
 	req.Var("serverSlug", serverSlug)
 	req.Var("serverRegion", serverRegion)
-
-	// Set auth header
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.accessToken))
+	req.Var("limit", attendancePageSize)
 
 	// Run the query
 	var response GuildResponse
-	if err := c.client.Run(ctx, req, &response); err != nil {
-		return nil, fmt.Errorf("error querying Warcraftlogs API: %w", err)
+	if err := c.run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	// Warcraftlogs answers unknown guilds with an empty guild instead of an error
+	if response.GuildData.Guild.Name == "" {
+		return nil, &Error{Kind: ErrNotFound, Client: c.GetClientName(), Message: "guild not found"}
+	}
+
+	response.Partial = c.completeAttendance(ctx, &response.GuildData.Guild.Attendance, name, serverSlug, serverRegion)
+	return &response, nil
+}
+
+// completeAttendance fetches the attendance pages after the first until they reach past the attendance
+// window, then drops the raids outside of it. The window ends at the most recent raid so guilds between
+// raid tiers still have attendance. Pages that fail to load are skipped, the raids so far are still useful.
+// It returns true when a page failed or was held back to save points before the pages covered the window.
+func (c *WarcraftlogsClient) completeAttendance(ctx context.Context, attendance *GuildAttendance, name, serverSlug, serverRegion string) bool {
+	if c.config.AttendanceWindow <= 0 || len(attendance.Data) == 0 {
+		return false
+	}
+	start := attendance.Data[0].Time().Add(-c.config.AttendanceWindow)
+
+	partial := false
+	for page := 2; page <= maxAttendancePages && attendance.HasMorePages; page++ {
+		if attendance.Data[len(attendance.Data)-1].Time().Before(start) {
+			break
+		}
+		if c.lowOnPoints(ctx, "GetGuildAttendance") {
+			partial = true
+			break
+		}
+
+		next, err := c.getAttendancePage(ctx, name, serverSlug, serverRegion, page)
+		if err != nil {
+			logging.FromContext(ctx).Warn("failed to get guild attendance page", "page", page, "error", err)
+			partial = true
+			break
+		}
+		attendance.Data = append(attendance.Data, next.Data...)
+		attendance.HasMorePages = next.HasMorePages
+	}
+
+	raids := attendance.Data[:0]
+	for _, raid := range attendance.Data {
+		if !raid.Time().Before(start) {
+			raids = append(raids, raid)
+		}
+	}
+	attendance.Data = raids
+
+	if partial {
+		logging.FromContext(ctx).Warn("guild attendance does not cover the attendance window",
+			"guild", name, "from", raids[len(raids)-1].Time(), "to", raids[0].Time(), "window", c.config.AttendanceWindow)
+	}
+	return partial
+}
+
+// getAttendancePage gets a single page of the raids of a guild
+func (c *WarcraftlogsClient) getAttendancePage(ctx context.Context, name, serverSlug, serverRegion string, page int) (*GuildAttendance, error) {
+	req := graphql.NewRequest(`
+		query GetGuildAttendance(
+			$name: String!
+			$serverSlug: String!
+			$serverRegion: String!
+			$limit: Int!
+			$page: Int!
+		)
+		{
+			guildData {
+				guild(name: $name, serverSlug: $serverSlug, serverRegion: $serverRegion) {
+					attendance(limit: $limit, page: $page){
+						data{
+							code
+							startTime
+							zone{
+								id
+								name
+							}
+							players{
+								name
+								type
+								presence
+							}
+						}
+						has_more_pages
+					}
+				}
+			}
+			rateLimitData {
+				limitPerHour
+				pointsSpentThisHour
+				pointsResetIn
+			}
+		}
+	`)
+
+	req.Var("name", name)
+	req.Var("serverSlug", serverSlug)
+	req.Var("serverRegion", serverRegion)
+	req.Var("limit", attendancePageSize)
+	req.Var("page", page)
+
+	var response struct {
+		GuildData struct {
+			Guild *struct {
+				Attendance GuildAttendance `json:"attendance"`
+			} `json:"guild"`
+		} `json:"guildData"`
+	}
+	if err := c.run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+	if response.GuildData.Guild == nil {
+		return nil, &Error{Kind: ErrNotFound, Client: c.GetClientName(), Message: "guild not found"}
+	}
+	return &response.GuildData.Guild.Attendance, nil
+}
+
+// GetCharacterRankings gets the best and median parses of a character in the current raid zone
+// on normal, heroic and mythic difficulty from the Warcraftlogs API
+func (c *WarcraftlogsClient) GetCharacterRankings(ctx context.Context, name, serverSlug, serverRegion string) (interface{}, error) {
+	req := graphql.NewRequest(`
+		query GetCharacterRankings(
+			$name: String!
+			$serverSlug: String!
+			$serverRegion: String!
+			$normal: Int!
+			$heroic: Int!
+			$mythic: Int!
+		)
+		{
+			characterData {
+				character(name: $name, serverSlug: $serverSlug, serverRegion: $serverRegion) {
+					name
+					classID
+					normal: zoneRankings(difficulty: $normal)
+					heroic: zoneRankings(difficulty: $heroic)
+					mythic: zoneRankings(difficulty: $mythic)
+				}
+			}
+			rateLimitData {
+				limitPerHour
+				pointsSpentThisHour
+				pointsResetIn
+			}
+		}
+	`)
+
+	req.Var("name", name)
+	req.Var("serverSlug", serverSlug)
+	req.Var("serverRegion", serverRegion)
+	req.Var("normal", DifficultyNormal)
+	req.Var("heroic", DifficultyHeroic)
+	req.Var("mythic", DifficultyMythic)
+
+	var response CharacterRankingsResponse
+	if err := c.run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	// Characters that were never logged are returned as null
+	if response.CharacterData.Character == nil {
+		return nil, &Error{Kind: ErrNotFound, Client: c.GetClientName(), Message: "character not found"}
+	}
+
+	return &response, nil
+}
+
+// reportsPageSize is how many reports are requested per page, the most Warcraftlogs allows
+const reportsPageSize = 100
+
+// maxProgressionPages bounds how many report pages a progression lookup requests
+const maxProgressionPages = 5
+
+// GetGuildProgression gets the boss pulls of a guild in a raid zone on a difficulty from the Warcraftlogs API.
+// A zero zone ID picks the most recent raid zone and a zero difficulty picks heroic.
+func (c *WarcraftlogsClient) GetGuildProgression(ctx context.Context, name, serverSlug, serverRegion string, zoneID, difficulty int) (interface{}, error) {
+	zones, err := c.getRaidZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := GuildProgressionResponse{Zones: zones, Difficulty: difficulty}
+	if response.Difficulty == 0 {
+		response.Difficulty = DifficultyHeroic
+	}
+	found := false
+	for _, zone := range zones {
+		if zone.ID == zoneID || zoneID == 0 {
+			response.Zone, found = zone, true
+			break
+		}
+	}
+	if !found || !response.Zone.HasDifficulty(response.Difficulty) {
+		return nil, &Error{Kind: ErrNotFound, Client: c.GetClientName(), Message: "zone not found"}
+	}
+
+	req := graphql.NewRequest(`
+		query GetGuildProgression(
+			$name: String!
+			$serverSlug: String!
+			$serverRegion: String!
+			$zoneID: Int!
+			$difficulty: Int!
+			$limit: Int!
+		)
+		{
+			guildData {
+				guild(name: $name, serverSlug: $serverSlug, serverRegion: $serverRegion) {
+					name
+					zoneRanking(zoneId: $zoneID){
+						progress{
+							serverRank{
+								number
+								color
+							}
+							regionRank{
+								number
+								color
+							}
+							worldRank{
+								number
+								color
+							}
+						}
+					}
+				}
+			}
+			reportData {
+				reports(guildName: $name, guildServerSlug: $serverSlug, guildServerRegion: $serverRegion, zoneID: $zoneID, limit: $limit, page: 1){
+					data{
+						code
+						startTime
+						fights(killType: Encounters, difficulty: $difficulty){
+							encounterID
+							kill
+							fightPercentage
+							startTime
+						}
+					}
+					has_more_pages
+				}
+			}
+			rateLimitData {
+				limitPerHour
+				pointsSpentThisHour
+				pointsResetIn
+			}
+		}
+	`)
+
+	req.Var("name", name)
+	req.Var("serverSlug", serverSlug)
+	req.Var("serverRegion", serverRegion)
+	req.Var("zoneID", response.Zone.ID)
+	req.Var("difficulty", response.Difficulty)
+	req.Var("limit", reportsPageSize)
+
+	if err := c.run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+	if response.GuildData.Guild == nil {
+		return nil, &Error{Kind: ErrNotFound, Client: c.GetClientName(), Message: "guild not found"}
+	}
+
+	// First kills are in the oldest reports, fetch the pages after the first
+	reports := &response.ReportData.Reports
+	for page := 2; page <= maxProgressionPages && reports.HasMorePages; page++ {
+		if c.lowOnPoints(ctx, "GetGuildProgressionReports") {
+			response.Partial = true
+			break
+		}
+		next, err := c.getProgressionReportsPage(ctx, name, serverSlug, serverRegion, response.Zone.ID, response.Difficulty, page)
+		if err != nil {
+			logging.FromContext(ctx).Warn("failed to get guild progression reports page", "page", page, "error", err)
+			break
+		}
+		reports.Data = append(reports.Data, next.Data...)
+		reports.HasMorePages = next.HasMorePages
+	}
+
+	return &response, nil
+}
+
+// getRaidZones gets the raid zones of the current expansion, most recent first
+func (c *WarcraftlogsClient) getRaidZones(ctx context.Context) ([]Zone, error) {
+	req := graphql.NewRequest(`
+		query GetRaidZones {
+			worldData {
+				expansions {
+					id
+					name
+					zones {
+						id
+						name
+						difficulties {
+							id
+							name
+						}
+						encounters {
+							id
+							name
+						}
+					}
+				}
+			}
+			rateLimitData {
+				limitPerHour
+				pointsSpentThisHour
+				pointsResetIn
+			}
+		}
+	`)
+
+	var response ZonesResponse
+	if err := c.run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	expansions := response.WorldData.Expansions
+	if len(expansions) == 0 {
+		return nil, &Error{Kind: ErrUpstream, Client: c.GetClientName(), Message: "no expansions"}
+	}
+	current := expansions[0]
+	for _, expansion := range expansions[1:] {
+		if expansion.ID > current.ID {
+			current = expansion
+		}
+	}
+
+	// Dungeon zones have no mythic raid difficulty
+	var zones []Zone
+	for _, zone := range current.Zones {
+		if zone.HasDifficulty(DifficultyMythic) {
+			zones = append(zones, zone)
+		}
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		return zones[i].ID > zones[j].ID
+	})
+	return zones, nil
+}
+
+// getProgressionReportsPage gets a single page of the boss pulls of a guild in a zone
+func (c *WarcraftlogsClient) getProgressionReportsPage(ctx context.Context, name, serverSlug, serverRegion string, zoneID, difficulty, page int) (*ProgressionReports, error) {
+	req := graphql.NewRequest(`
+		query GetGuildProgressionReports(
+			$name: String!
+			$serverSlug: String!
+			$serverRegion: String!
+			$zoneID: Int!
+			$difficulty: Int!
+			$limit: Int!
+			$page: Int!
+		)
+		{
+			reportData {
+				reports(guildName: $name, guildServerSlug: $serverSlug, guildServerRegion: $serverRegion, zoneID: $zoneID, limit: $limit, page: $page){
+					data{
+						code
+						startTime
+						fights(killType: Encounters, difficulty: $difficulty){
+							encounterID
+							kill
+							fightPercentage
+							startTime
+						}
+					}
+					has_more_pages
+				}
+			}
+			rateLimitData {
+				limitPerHour
+				pointsSpentThisHour
+				pointsResetIn
+			}
+		}
+	`)
+
+	req.Var("name", name)
+	req.Var("serverSlug", serverSlug)
+	req.Var("serverRegion", serverRegion)
+	req.Var("zoneID", zoneID)
+	req.Var("difficulty", difficulty)
+	req.Var("limit", reportsPageSize)
+	req.Var("page", page)
+
+	var response struct {
+		ReportData struct {
+			Reports ProgressionReports `json:"reports"`
+		} `json:"reportData"`
+	}
+	if err := c.run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+	return &response.ReportData.Reports, nil
+}
+
+// guildReportsPageSize is how many reports are listed per page of the report browser
+const guildReportsPageSize = 20
+
+// GetGuildReports gets a page of the most recent reports of a guild from the Warcraftlogs API, starting at page 1
+func (c *WarcraftlogsClient) GetGuildReports(ctx context.Context, name, serverSlug, serverRegion string, page int) (interface{}, error) {
+	req := graphql.NewRequest(`
+		query GetGuildReports(
+			$name: String!
+			$serverSlug: String!
+			$serverRegion: String!
+			$limit: Int!
+			$page: Int!
+		)
+		{
+			guildData {
+				guild(name: $name, serverSlug: $serverSlug, serverRegion: $serverRegion) {
+					name
+				}
+			}
+			reportData {
+				reports(guildName: $name, guildServerSlug: $serverSlug, guildServerRegion: $serverRegion, limit: $limit, page: $page){
+					data{
+						code
+						title
+						startTime
+						endTime
+						zone{
+							id
+							name
+						}
+						owner{
+							name
+						}
+					}
+					total
+					per_page
+					current_page
+					last_page
+					has_more_pages
+				}
+			}
+			rateLimitData {
+				limitPerHour
+				pointsSpentThisHour
+				pointsResetIn
+			}
+		}
+	`)
+
+	req.Var("name", name)
+	req.Var("serverSlug", serverSlug)
+	req.Var("serverRegion", serverRegion)
+	req.Var("limit", guildReportsPageSize)
+	req.Var("page", max(page, 1))
+
+	var response GuildReportsResponse
+	if err := c.run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+	if response.GuildData.Guild == nil {
+		return nil, &Error{Kind: ErrNotFound, Client: c.GetClientName(), Message: "guild not found"}
 	}
 
 	return &response, nil
 }
+
+// GetReport gets a report with its boss pulls from the Warcraftlogs API
+func (c *WarcraftlogsClient) GetReport(ctx context.Context, code string) (interface{}, error) {
+	req := graphql.NewRequest(`
+		query GetReport($code: String!) {
+			reportData {
+				report(code: $code) {
+					code
+					title
+					startTime
+					endTime
+					zone{
+						id
+						name
+					}
+					owner{
+						name
+					}
+					guild{
+						name
+						server{
+							slug
+							region{
+								slug
+							}
+						}
+					}
+					fights(killType: Encounters){
+						id
+						encounterID
+						name
+						difficulty
+						kill
+						bossPercentage
+						fightPercentage
+						startTime
+						endTime
+					}
+				}
+			}
+			rateLimitData {
+				limitPerHour
+				pointsSpentThisHour
+				pointsResetIn
+			}
+		}
+	`)
+
+	req.Var("code", code)
+
+	var response ReportResponse
+	if err := c.run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+	if response.ReportData.Report == nil {
+		return nil, &Error{Kind: ErrNotFound, Client: c.GetClientName(), Message: "report not found"}
+	}
+
+	return &response, nil
+}
+
+// GetReportTable gets the table of a data type, one of TableDataTypes, for a fight of a report from the Warcraftlogs API
+func (c *WarcraftlogsClient) GetReportTable(ctx context.Context, code string, fightID int, dataType string) (interface{}, error) {
+	if !slices.Contains(TableDataTypes, dataType) {
+		return nil, &Error{Kind: ErrNotFound, Client: c.GetClientName(), Message: "unknown table data type " + dataType}
+	}
+
+	// Tables are the most expensive queries, the points left are kept for guilds and characters
+	if c.lowOnPoints(ctx, "GetReportTable") {
+		retryAfter := max(0, time.Until(c.points.status().ResetAt))
+		return nil, &Error{Kind: ErrRateLimited, Client: c.GetClientName(), Message: "Warcraftlogs points below the floor", RetryAfter: retryAfter}
+	}
+
+	req := graphql.NewRequest(`
+		query GetReportTable(
+			$code: String!
+			$fightID: Int!
+			$dataType: TableDataType!
+		)
+		{
+			reportData {
+				report(code: $code) {
+					code
+					title
+					fights(fightIDs: [$fightID]){
+						id
+						encounterID
+						name
+						difficulty
+						kill
+						bossPercentage
+						fightPercentage
+						startTime
+						endTime
+					}
+					table(fightIDs: [$fightID], dataType: $dataType)
+				}
+			}
+			rateLimitData {
+				limitPerHour
+				pointsSpentThisHour
+				pointsResetIn
+			}
+		}
+	`)
+
+	req.Var("code", code)
+	req.Var("fightID", fightID)
+	req.Var("dataType", dataType)
+
+	var response ReportTableResponse
+	if err := c.run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+	if response.ReportData.Report == nil {
+		return nil, &Error{Kind: ErrNotFound, Client: c.GetClientName(), Message: "report not found"}
+	}
+	if len(response.ReportData.Report.Fights) == 0 {
+		return nil, &Error{Kind: ErrNotFound, Client: c.GetClientName(), Message: "fight not found"}
+	}
+
+	response.DataType = dataType
+	return &response, nil
+}
+
+// Ping runs a minimal query to check that the Warcraftlogs API is reachable and accepts the access token
+func (c *WarcraftlogsClient) Ping(ctx context.Context) error {
+	req := graphql.NewRequest(`
+		query Ping {
+			rateLimitData {
+				limitPerHour
+				pointsSpentThisHour
+				pointsResetIn
+			}
+		}
+	`)
+	// The points budget is recorded by run like for every other query
+	var response struct{}
+	return c.run(ctx, req, &response)
+}
+
+// PointsStatus returns the points budget of the current hour as last reported by the Warcraftlogs API
+func (c *WarcraftlogsClient) PointsStatus() interface{} {
+	return c.points.status()
+}
+
+// lowOnPoints reports whether the points budget is below the floor, recording that the query is held back
+func (c *WarcraftlogsClient) lowOnPoints(ctx context.Context, query string) bool {
+	status := c.points.status()
+	if !status.Low {
+		return false
+	}
+	logging.FromContext(ctx).Warn("Warcraftlogs points below the floor, holding back query", "query", query, "remaining", status.Remaining, "floor", status.Floor)
+	metrics.ObserveWarcraftlogsDegraded(query)
+	return true
+}
+
+// run runs a query with an access token, retrying once with a fresh OAuth token when it is rejected
+func (c *WarcraftlogsClient) run(ctx context.Context, req *graphql.Request, response interface{}) error {
+	accessToken, oauth, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.runWithToken(ctx, req, response, accessToken)
+	if oauth && errors.Is(err, ErrUnauthorized) {
+		// The token was revoked or expired early, retry once with a fresh one
+		c.tokens.Invalidate()
+		if accessToken, _, err = c.accessToken(ctx); err != nil {
+			return err
+		}
+		err = c.runWithToken(ctx, req, response, accessToken)
+	}
+	return err
+}
+
+// accessToken returns the token to authenticate queries with and whether it is an OAuth token.
+// It falls back to the static token when no token provider is configured or the token endpoint fails,
+// and keeps using it for tokenFailureBackoff before trying the token endpoint again.
+func (c *WarcraftlogsClient) accessToken(ctx context.Context) (string, bool, error) {
+	if c.tokens == nil {
+		return c.staticToken, false, nil
+	}
+
+	c.mu.Lock()
+	backingOff := c.staticToken != "" && time.Now().Before(c.staticUntil)
+	c.mu.Unlock()
+	if backingOff {
+		return c.staticToken, false, nil
+	}
+
+	accessToken, err := c.tokens.Token(ctx)
+	if err == nil {
+		return accessToken, true, nil
+	}
+	if c.staticToken == "" || ctx.Err() != nil {
+		return "", false, err
+	}
+	logging.FromContext(ctx).Warn("failed to get Warcraftlogs access token, using the static token", "error", err, "retry_in", tokenFailureBackoff)
+
+	c.mu.Lock()
+	c.staticUntil = time.Now().Add(tokenFailureBackoff)
+	c.mu.Unlock()
+	return c.staticToken, false, nil
+}
+
+// runWithToken runs a query with the given access token, turning failed responses and GraphQL errors into typed errors
+func (c *WarcraftlogsClient) runWithToken(ctx context.Context, req *graphql.Request, response interface{}, accessToken string) error {
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+
+	points := &pointsResponse{response: response}
+	err := c.client.Run(ctx, req, points)
+	if points.rateLimitData != nil {
+		c.points.update(*points.rateLimitData)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return newRequestError(c.GetClientName(), err)
+	}
+
+	// GraphQL errors come with a successful response, unknown reports and characters are reported
+	// with an error such as "This report does not exist" instead of a null field
+	message := strings.TrimPrefix(err.Error(), "graphql: ")
+	if notFoundMessage(message) {
+		return &Error{Kind: ErrNotFound, Client: c.GetClientName(), Message: message}
+	}
+	return &Error{Kind: ErrUpstream, Client: c.GetClientName(), Message: message}
+}
+
+// notFoundMessage reports whether a GraphQL error message says the requested resource does not exist
+func notFoundMessage(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "does not exist") || strings.Contains(message, "not found")
+}
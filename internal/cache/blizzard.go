@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+	"wowarmory/internal/api"
+	"wowarmory/internal/config"
+	"wowarmory/internal/interfaces"
+	"wowarmory/internal/logging"
+	"wowarmory/internal/metrics"
+)
+
+// partialCharacterTTL is how long a character with failed sections is cached, so they are retried soon
+const partialCharacterTTL = time.Minute
+
+// BlizzardCache is a BlizzardAPI that caches characters in a CacheStore.
+// Expired characters are revalidated with the Last-Modified time of their profile
+// and only fetched again when they changed.
+type BlizzardCache struct {
+	client interfaces.BlizzardAPI
+	store  interfaces.CacheStore
+	config config.CacheConfig
+}
+
+// Ensure BlizzardCache implements BlizzardAPI, CharacterCache and GuildRosterCache interfaces
+var _ interfaces.BlizzardAPI = (*BlizzardCache)(nil)
+var _ interfaces.CharacterCache = (*BlizzardCache)(nil)
+var _ interfaces.GuildRosterCache = (*BlizzardCache)(nil)
+
+// NewBlizzardCache creates a new caching decorator around a Blizzard API client
+func NewBlizzardCache(client interfaces.BlizzardAPI, store interfaces.CacheStore, cfg config.CacheConfig) *BlizzardCache {
+	return &BlizzardCache{
+		client: client,
+		store:  store,
+		config: cfg,
+	}
+}
+
+// GetClientName returns the name of the wrapped client
+func (c *BlizzardCache) GetClientName() string {
+	return c.client.GetClientName()
+}
+
+// GetAccessToken returns the access token of the wrapped client
+func (c *BlizzardCache) GetAccessToken(ctx context.Context) (string, error) {
+	return c.client.GetAccessToken(ctx)
+}
+
+// CharacterModifiedSince asks the wrapped client whether the character changed
+func (c *BlizzardCache) CharacterModifiedSince(ctx context.Context, region, realm, character, lastModified string) (bool, error) {
+	return c.client.CharacterModifiedSince(ctx, region, realm, character, lastModified)
+}
+
+// GetCharacterProfile returns the cached character if it is still fresh or unchanged upstream,
+// otherwise it fetches the character from the wrapped client and caches it
+func (c *BlizzardCache) GetCharacterProfile(ctx context.Context, region, realm, character string) (interface{}, error) {
+	key := cacheKey("character", region, realm, character)
+
+	if cached := load(ctx, c.store, key); cached != nil {
+		if response, ok := c.revalidate(ctx, key, cached, region, realm, character); ok {
+			return response, nil
+		}
+	}
+	metrics.ObserveCache("character", metrics.CacheMiss)
+
+	response, err := c.client.GetCharacterProfile(ctx, region, realm, character)
+	if err != nil {
+		return nil, err
+	}
+
+	if character, ok := response.(*api.CharacterResponse); ok {
+		c.saveCharacter(ctx, key, character)
+	}
+	return response, nil
+}
+
+// InvalidateCharacter drops the cached character so the next lookup fetches it again
+func (c *BlizzardCache) InvalidateCharacter(ctx context.Context, region, realm, character string) error {
+	return c.store.DeleteCache(ctx, cacheKey("character", region, realm, character))
+}
+
+// GetGuildRoster returns the cached roster if it is still fresh, otherwise it fetches the roster
+// from the wrapped client and caches it for as long as guilds. Failed lookups are not cached.
+func (c *BlizzardCache) GetGuildRoster(ctx context.Context, region, realm, guild string) (interface{}, error) {
+	key := cacheKey("roster", region, realm, guild)
+
+	if cached := load(ctx, c.store, key); cached != nil && cached.fresh() {
+		var response api.GuildRosterResponse
+		if err := json.Unmarshal(cached.Response, &response); err == nil {
+			metrics.ObserveCache("roster", metrics.CacheHit)
+			return &response, nil
+		}
+		logging.FromContext(ctx).Error("failed to decode cached guild roster", "key", key)
+	}
+	metrics.ObserveCache("roster", metrics.CacheMiss)
+
+	response, err := c.client.GetGuildRoster(ctx, region, realm, guild)
+	if err != nil {
+		return nil, err
+	}
+
+	if roster, ok := response.(*api.GuildRosterResponse); ok {
+		data, err := json.Marshal(roster)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to encode guild roster", "key", key, "error", err)
+			return response, nil
+		}
+		save(ctx, c.store, key, &entry{
+			FreshUntil: time.Now().Add(c.config.GuildTTL),
+			Response:   data,
+		}, c.config.GuildTTL)
+	}
+	return response, nil
+}
+
+// InvalidateGuildRoster drops the cached roster so the next lookup fetches it again
+func (c *BlizzardCache) InvalidateGuildRoster(ctx context.Context, region, realm, guild string) error {
+	return c.store.DeleteCache(ctx, cacheKey("roster", region, realm, guild))
+}
+
+// revalidate returns the cached character if it is fresh, or if Blizzard reports that it did not change
+// since it was cached. Characters with failed sections are never revalidated so the sections are retried.
+func (c *BlizzardCache) revalidate(ctx context.Context, key string, cached *entry, region, realm, character string) (*api.CharacterResponse, bool) {
+	result := metrics.CacheHit
+	if !cached.fresh() {
+		if cached.LastModified == "" || len(cached.Failed) > 0 {
+			return nil, false
+		}
+
+		modified, err := c.client.CharacterModifiedSince(ctx, region, realm, character, cached.LastModified)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to revalidate cached character", "key", key, "error", err)
+			return nil, false
+		}
+		if modified {
+			return nil, false
+		}
+
+		cached.FreshUntil = time.Now().Add(c.config.CharacterTTL)
+		save(ctx, c.store, key, cached, c.config.CharacterTTL+c.config.CharacterRevalidateTTL)
+		result = metrics.CacheRevalidated
+	}
+
+	var response api.CharacterResponse
+	if err := json.Unmarshal(cached.Response, &response); err != nil {
+		logging.FromContext(ctx).Error("failed to decode cached character", "key", key, "error", err)
+		return nil, false
+	}
+
+	response.Errors = make(map[string]error, len(cached.Failed))
+	for name, message := range cached.Failed {
+		response.Errors[name] = errors.New(message)
+	}
+	metrics.ObserveCache("character", result)
+	return &response, true
+}
+
+// saveCharacter caches a character. Complete characters with a Last-Modified time are kept
+// after they expire so they can be revalidated, partial characters expire quickly.
+func (c *BlizzardCache) saveCharacter(ctx context.Context, key string, response *api.CharacterResponse) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to encode character", "key", key, "error", err)
+		return
+	}
+
+	cached := &entry{
+		LastModified: response.LastModified,
+		Response:     data,
+	}
+
+	// Sections Blizzard has no data for are not failures, the character is still complete without them
+	freshFor, keepFor := c.config.CharacterTTL, c.config.CharacterTTL
+	if failed := response.FailedRequests(); len(failed) > 0 {
+		cached.Failed = make(map[string]string, len(failed))
+		for _, name := range failed {
+			cached.Failed[name] = response.Errors[name].Error()
+		}
+		freshFor, keepFor = partialCharacterTTL, partialCharacterTTL
+	} else if response.LastModified != "" {
+		keepFor += c.config.CharacterRevalidateTTL
+	}
+
+	cached.FreshUntil = time.Now().Add(freshFor)
+	save(ctx, c.store, key, cached, keepFor)
+}
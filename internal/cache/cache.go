@@ -0,0 +1,86 @@
+// Package cache provides decorators that cache upstream API responses in a CacheStore
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+	"wowarmory/internal/interfaces"
+	"wowarmory/internal/logging"
+)
+
+const (
+	// keyPrefix is prepended to every cache key
+	keyPrefix = "cache"
+
+	// storeTimeout is the maximum time spent on a single cache operation
+	storeTimeout = 2 * time.Second
+)
+
+// entry is a cached response together with the metadata needed to revalidate it
+type entry struct {
+	// FreshUntil is when the response should no longer be served without revalidation
+	FreshUntil time.Time `json:"fresh_until"`
+
+	// LastModified is the upstream Last-Modified header of the response, if any
+	LastModified string `json:"last_modified,omitempty"`
+
+	// Failed holds the error messages of the sub-requests that failed, keyed by request name
+	Failed map[string]string `json:"failed,omitempty"`
+
+	// Response is the normalized upstream response
+	Response json.RawMessage `json:"response"`
+}
+
+// fresh reports whether the entry can be served without revalidation
+func (e *entry) fresh() bool {
+	return time.Now().Before(e.FreshUntil)
+}
+
+// cacheKey builds a cache key from the given parts, which are normalized to lowercase slugs
+func cacheKey(kind string, parts ...string) string {
+	key := keyPrefix + ":" + kind
+	for _, part := range parts {
+		key += ":" + strings.ReplaceAll(strings.ToLower(strings.TrimSpace(part)), " ", "-")
+	}
+	return key
+}
+
+// load gets an entry from the store, returning nil when it is not cached or unreadable
+func load(ctx context.Context, store interfaces.CacheStore, key string) *entry {
+	ctx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+
+	data, err := store.GetCache(ctx, key)
+	if err != nil {
+		if err != interfaces.ErrCacheMiss {
+			logging.FromContext(ctx).Error("failed to read cache", "key", key, "error", err)
+		}
+		return nil
+	}
+
+	var cached entry
+	if err := json.Unmarshal(data, &cached); err != nil {
+		logging.FromContext(ctx).Error("failed to decode cache entry", "key", key, "error", err)
+		return nil
+	}
+	return &cached
+}
+
+// save stores an entry that expires from the store after the given duration.
+// Failures are only logged, the response is still served without caching it.
+func save(ctx context.Context, store interfaces.CacheStore, key string, cached *entry, ttl time.Duration) {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to encode cache entry", "key", key, "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+
+	if err := store.SetCache(ctx, key, data, ttl); err != nil {
+		logging.FromContext(ctx).Error("failed to write cache", "key", key, "error", err)
+	}
+}
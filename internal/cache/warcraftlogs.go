@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+	"wowarmory/internal/api"
+	"wowarmory/internal/config"
+	"wowarmory/internal/interfaces"
+	"wowarmory/internal/logging"
+	"wowarmory/internal/metrics"
+)
+
+// partialGuildTTL is how long a guild or progression that stopped paging early is cached, so it is completed soon
+const partialGuildTTL = time.Minute
+
+// WarcraftlogsCache is a WarcraftLogsAPI that caches guilds, guild progression and character rankings in a CacheStore
+type WarcraftlogsCache struct {
+	client interfaces.WarcraftLogsAPI
+	store  interfaces.CacheStore
+	config config.CacheConfig
+}
+
+// Ensure WarcraftlogsCache implements WarcraftLogsAPI, GuildCache, CharacterRankingsCache and GuildProgressionCache interfaces
+var _ interfaces.WarcraftLogsAPI = (*WarcraftlogsCache)(nil)
+var _ interfaces.GuildCache = (*WarcraftlogsCache)(nil)
+var _ interfaces.CharacterRankingsCache = (*WarcraftlogsCache)(nil)
+var _ interfaces.GuildProgressionCache = (*WarcraftlogsCache)(nil)
+
+// NewWarcraftlogsCache creates a new caching decorator around a Warcraftlogs API client
+func NewWarcraftlogsCache(client interfaces.WarcraftLogsAPI, store interfaces.CacheStore, cfg config.CacheConfig) *WarcraftlogsCache {
+	return &WarcraftlogsCache{
+		client: client,
+		store:  store,
+		config: cfg,
+	}
+}
+
+// GetClientName returns the name of the wrapped client
+func (c *WarcraftlogsCache) GetClientName() string {
+	return c.client.GetClientName()
+}
+
+// GetGuild returns the cached guild if it is still fresh, otherwise it fetches the guild
+// from the wrapped client and caches it. Failed lookups, including guilds that were not found, are not cached
+// and partial guilds are only cached briefly.
+func (c *WarcraftlogsCache) GetGuild(ctx context.Context, name, serverSlug, serverRegion string) (interface{}, error) {
+	key := cacheKey("guild", serverRegion, serverSlug, name)
+
+	if cached := load(ctx, c.store, key); cached != nil && cached.fresh() {
+		var response api.GuildResponse
+		if err := json.Unmarshal(cached.Response, &response); err == nil {
+			metrics.ObserveCache("guild", metrics.CacheHit)
+			return &response, nil
+		}
+		logging.FromContext(ctx).Error("failed to decode cached guild", "key", key)
+	}
+	metrics.ObserveCache("guild", metrics.CacheMiss)
+
+	response, err := c.client.GetGuild(ctx, name, serverSlug, serverRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	if guild, ok := response.(*api.GuildResponse); ok {
+		data, err := json.Marshal(guild)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to encode guild", "key", key, "error", err)
+			return response, nil
+		}
+		ttl := c.config.GuildTTL
+		if guild.Partial {
+			ttl = partialGuildTTL
+		}
+		save(ctx, c.store, key, &entry{
+			FreshUntil: time.Now().Add(ttl),
+			Response:   data,
+		}, ttl)
+	}
+	return response, nil
+}
+
+// GetCharacterRankings returns the cached rankings if they are still fresh, otherwise it fetches them
+// from the wrapped client and caches them. Failed lookups are not cached.
+func (c *WarcraftlogsCache) GetCharacterRankings(ctx context.Context, name, serverSlug, serverRegion string) (interface{}, error) {
+	key := cacheKey("rankings", serverRegion, serverSlug, name)
+
+	if cached := load(ctx, c.store, key); cached != nil && cached.fresh() {
+		var response api.CharacterRankingsResponse
+		if err := json.Unmarshal(cached.Response, &response); err == nil {
+			metrics.ObserveCache("rankings", metrics.CacheHit)
+			return &response, nil
+		}
+		logging.FromContext(ctx).Error("failed to decode cached rankings", "key", key)
+	}
+	metrics.ObserveCache("rankings", metrics.CacheMiss)
+
+	response, err := c.client.GetCharacterRankings(ctx, name, serverSlug, serverRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	if rankings, ok := response.(*api.CharacterRankingsResponse); ok {
+		data, err := json.Marshal(rankings)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to encode rankings", "key", key, "error", err)
+			return response, nil
+		}
+		save(ctx, c.store, key, &entry{
+			FreshUntil: time.Now().Add(c.config.RankingsTTL),
+			Response:   data,
+		}, c.config.RankingsTTL)
+	}
+	return response, nil
+}
+
+// GetGuildProgression returns the cached progression if it is still fresh, otherwise it fetches it
+// from the wrapped client and caches it with the guild TTL. Failed lookups are not cached and partial
+// progression is only cached briefly.
+func (c *WarcraftlogsCache) GetGuildProgression(ctx context.Context, name, serverSlug, serverRegion string, zoneID, difficulty int) (interface{}, error) {
+	key := progressionCacheKey(name, serverSlug, serverRegion, zoneID, difficulty)
+
+	if cached := load(ctx, c.store, key); cached != nil && cached.fresh() {
+		var response api.GuildProgressionResponse
+		if err := json.Unmarshal(cached.Response, &response); err == nil {
+			metrics.ObserveCache("progression", metrics.CacheHit)
+			return &response, nil
+		}
+		logging.FromContext(ctx).Error("failed to decode cached guild progression", "key", key)
+	}
+	metrics.ObserveCache("progression", metrics.CacheMiss)
+
+	response, err := c.client.GetGuildProgression(ctx, name, serverSlug, serverRegion, zoneID, difficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	if progression, ok := response.(*api.GuildProgressionResponse); ok {
+		data, err := json.Marshal(progression)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to encode guild progression", "key", key, "error", err)
+			return response, nil
+		}
+		ttl := c.config.GuildTTL
+		if progression.Partial {
+			ttl = partialGuildTTL
+		}
+		save(ctx, c.store, key, &entry{
+			FreshUntil: time.Now().Add(ttl),
+			Response:   data,
+		}, ttl)
+	}
+	return response, nil
+}
+
+// GetGuildReports gets the reports of a guild from the wrapped client, they are not cached
+// because the most recent report changes while a raid is logged live
+func (c *WarcraftlogsCache) GetGuildReports(ctx context.Context, name, serverSlug, serverRegion string, page int) (interface{}, error) {
+	return c.client.GetGuildReports(ctx, name, serverSlug, serverRegion, page)
+}
+
+// GetReport gets a report from the wrapped client, it is not cached because reports change while
+// a raid is logged live
+func (c *WarcraftlogsCache) GetReport(ctx context.Context, code string) (interface{}, error) {
+	return c.client.GetReport(ctx, code)
+}
+
+// GetReportTable gets a report table from the wrapped client, it is not cached like the report itself
+func (c *WarcraftlogsCache) GetReportTable(ctx context.Context, code string, fightID int, dataType string) (interface{}, error) {
+	return c.client.GetReportTable(ctx, code, fightID, dataType)
+}
+
+// Ping checks the wrapped client, it is never cached
+func (c *WarcraftlogsCache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx)
+}
+
+// PointsStatus returns the points budget of the wrapped client
+func (c *WarcraftlogsCache) PointsStatus() interface{} {
+	return c.client.PointsStatus()
+}
+
+// InvalidateGuild drops the cached guild so the next lookup fetches it again
+func (c *WarcraftlogsCache) InvalidateGuild(ctx context.Context, name, serverSlug, serverRegion string) error {
+	return c.store.DeleteCache(ctx, cacheKey("guild", serverRegion, serverSlug, name))
+}
+
+// InvalidateCharacterRankings drops the cached rankings so the next lookup fetches them again
+func (c *WarcraftlogsCache) InvalidateCharacterRankings(ctx context.Context, name, serverSlug, serverRegion string) error {
+	return c.store.DeleteCache(ctx, cacheKey("rankings", serverRegion, serverSlug, name))
+}
+
+// InvalidateGuildProgression drops the cached progression so the next lookup fetches it again
+func (c *WarcraftlogsCache) InvalidateGuildProgression(ctx context.Context, name, serverSlug, serverRegion string, zoneID, difficulty int) error {
+	return c.store.DeleteCache(ctx, progressionCacheKey(name, serverSlug, serverRegion, zoneID, difficulty))
+}
+
+// progressionCacheKey returns the cache key of the progression of a guild in a zone on a difficulty
+func progressionCacheKey(name, serverSlug, serverRegion string, zoneID, difficulty int) string {
+	return cacheKey("progression", serverRegion, serverSlug, name, strconv.Itoa(zoneID), strconv.Itoa(difficulty))
+}
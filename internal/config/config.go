@@ -2,25 +2,214 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Port                 int
-	ClientID             string
-	ClientSecret         string
-	TemplatesDir         string
-	AssetsDir            string
-	Redis                RedisConfig
+	Port                     int
+	ClientID                 string
+	ClientSecret             string
+	TemplatesDir             string
+	AssetsDir                string
+	Redis                    RedisConfig
+	Server                   ServerConfig
+	Upstream                 UpstreamConfig
+	Cache                    CacheConfig
+	Lookup                   LookupConfig
+	Log                      LogConfig
+	RateLimit                RateLimitConfig
+	Warcraftlogs             WarcraftlogsConfig
+	WarcraftlogsClientID     string
+	WarcraftlogsClientSecret string
+	// WarcraftlogsAPIToken is a static Warcraftlogs token, used when no Warcraftlogs client credentials
+	// are configured or the Warcraftlogs token endpoint fails
 	WarcraftlogsAPIToken string
 }
 
+// LogConfig holds the logging configuration
+type LogConfig struct {
+	// Format is json or text
+	Format string
+	// Level is debug, info, warn or error
+	Level string
+}
+
+// RateLimitConfig holds the per client IP request limits
+type RateLimitConfig struct {
+	// Enabled turns the rate limiter on
+	Enabled bool
+	// TrustedProxies are the networks whose Fly-Client-IP and X-Forwarded-For headers are trusted
+	TrustedProxies []netip.Prefix
+	// Limits maps limit names such as character to their limit, every limit has one bucket per client
+	// shared by all routes using it
+	Limits map[string]RateLimit
+	// Routes maps request paths to the name of their limit, paths ending in / also match the paths below
+	// them except for the root, which only matches lookups on itself so static assets are not limited
+	Routes map[string]string
+}
+
+// Names of the rate limits, routes making the same upstream calls share a limit
+const (
+	CharacterRateLimit = "character"
+	GuildRateLimit     = "guild"
+	ReportRateLimit    = "report"
+	TokenRateLimit     = "token"
+	APIRateLimit       = "api"
+)
+
+// RateLimit allows Requests per Period for each client, in bursts of up to Requests
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// CacheConfig holds how long upstream API responses are cached
+type CacheConfig struct {
+	// CharacterTTL is how long a character is served from the cache without asking Blizzard
+	CharacterTTL time.Duration
+	// CharacterRevalidateTTL is how long an expired character is kept to revalidate it with Last-Modified
+	CharacterRevalidateTTL time.Duration
+	// GuildTTL is how long a guild is served from the cache
+	GuildTTL time.Duration
+	// RankingsTTL is how long the Warcraftlogs parses of a character are served from the cache
+	RankingsTTL time.Duration
+}
+
+// WarcraftlogsConfig holds how much data is requested from the Warcraftlogs API
+type WarcraftlogsConfig struct {
+	// AttendanceWindow is how far back guild attendance is fetched, counted from the most recent raid.
+	// A zero window only fetches the most recent page of raids.
+	AttendanceWindow time.Duration
+	// PointsFloor is how many Warcraftlogs points of the hour are kept for cheap queries, expensive ones
+	// such as full attendance are refused or cut short below it. Zero never holds back.
+	PointsFloor int
+}
+
+// LookupConfig holds how long the handlers wait for upstream lookups, a zero timeout only ends
+// the lookup when the client goes away
+type LookupConfig struct {
+	// CharacterTimeout is how long a character lookup may take, including all of its profile resources
+	CharacterTimeout time.Duration
+	// GuildTimeout is how long a guild lookup may take
+	GuildTimeout time.Duration
+	// TokenTimeout is how long a WoW Token price lookup may take
+	TokenTimeout time.Duration
+}
+
+// ServerConfig holds the HTTP server timeouts
+type ServerConfig struct {
+	// ReadHeaderTimeout is how long a client may take to send the request headers
+	ReadHeaderTimeout time.Duration
+	// ReadTimeout is how long a client may take to send the whole request
+	ReadTimeout time.Duration
+	// WriteTimeout is how long a request may take until the response is written
+	WriteTimeout time.Duration
+	// IdleTimeout is how long keep-alive connections are kept open between requests
+	IdleTimeout time.Duration
+	// ShutdownTimeout is how long in-flight requests may take to finish on shutdown
+	ShutdownTimeout time.Duration
+}
+
+// UpstreamConfig holds the base URLs of the external APIs
+type UpstreamConfig struct {
+	// BlizzardOAuthURL is the Blizzard OAuth token endpoint
+	BlizzardOAuthURL string
+	// BlizzardAPIURL is the Blizzard API base URL, {region} is replaced with the request region
+	BlizzardAPIURL string
+	// WarcraftlogsOAuthURL is the Warcraftlogs OAuth token endpoint
+	WarcraftlogsOAuthURL string
+	// WarcraftlogsAPIURL is the Warcraftlogs GraphQL endpoint
+	WarcraftlogsAPIURL string
+	// BlizzardRegionBudget is how many Blizzard API calls the app makes per region at most
+	BlizzardRegionBudget RateLimit
+}
+
+const (
+	// DefaultBlizzardOAuthURL is the default Blizzard OAuth token endpoint
+	DefaultBlizzardOAuthURL = "https://oauth.battle.net/oauth/token"
+
+	// DefaultBlizzardAPIURL is the default Blizzard API base URL
+	DefaultBlizzardAPIURL = "https://{region}.api.blizzard.com"
+
+	// DefaultWarcraftlogsOAuthURL is the default Warcraftlogs OAuth token endpoint
+	DefaultWarcraftlogsOAuthURL = "https://www.warcraftlogs.com/oauth/token"
+
+	// DefaultWarcraftlogsAPIURL is the default Warcraftlogs GraphQL endpoint
+	DefaultWarcraftlogsAPIURL = "https://www.warcraftlogs.com/api/v2/client"
+
+	// DefaultReadHeaderTimeout is the default time a client may take to send the request headers
+	DefaultReadHeaderTimeout = 5 * time.Second
+
+	// DefaultReadTimeout is the default time a client may take to send the whole request
+	DefaultReadTimeout = 10 * time.Second
+
+	// DefaultWriteTimeout is the default time a request may take, longer than the slowest upstream lookup
+	DefaultWriteTimeout = 30 * time.Second
+
+	// DefaultIdleTimeout is the default time keep-alive connections are kept open
+	DefaultIdleTimeout = 120 * time.Second
+
+	// DefaultShutdownTimeout is the default time in-flight requests may take to finish on shutdown
+	DefaultShutdownTimeout = 25 * time.Second
+
+	// DefaultCharacterLookupTimeout is the default time a character lookup may take, below the write timeout
+	DefaultCharacterLookupTimeout = 15 * time.Second
+
+	// DefaultGuildLookupTimeout is the default time a guild lookup may take
+	DefaultGuildLookupTimeout = 10 * time.Second
+
+	// DefaultTokenLookupTimeout is the default time a WoW Token price lookup may take
+	DefaultTokenLookupTimeout = 5 * time.Second
+
+	// DefaultAttendanceWindow is the default time guild attendance is fetched for, eight weeks of raids
+	DefaultAttendanceWindow = 8 * 7 * 24 * time.Hour
+
+	// DefaultWarcraftlogsPointsFloor is the default Warcraftlogs points kept for cheap queries, a tenth
+	// of the 3600 points per hour of a Warcraftlogs client
+	DefaultWarcraftlogsPointsFloor = 360
+
+	// DefaultCharacterTTL is the default time a character is cached
+	DefaultCharacterTTL = 10 * time.Minute
+
+	// DefaultCharacterRevalidateTTL is the default time an expired character is kept for revalidation
+	DefaultCharacterRevalidateTTL = 24 * time.Hour
+
+	// DefaultGuildTTL is the default time a guild is cached
+	DefaultGuildTTL = 30 * time.Minute
+
+	// DefaultRankingsTTL is the default time the parses of a character are cached
+	DefaultRankingsTTL = 30 * time.Minute
+)
+
+var (
+	// DefaultCharacterRateLimit is the default limit of character lookups, each costs about ten Blizzard requests
+	DefaultCharacterRateLimit = RateLimit{Requests: 20, Period: time.Minute}
+
+	// DefaultGuildRateLimit is the default limit of guild lookups
+	DefaultGuildRateLimit = RateLimit{Requests: 20, Period: time.Minute}
+
+	// DefaultReportRateLimit is the default limit of report lookups, fight tables cost Warcraftlogs points
+	DefaultReportRateLimit = RateLimit{Requests: 20, Period: time.Minute}
+
+	// DefaultTokenRateLimit is the default limit of WoW Token price lookups
+	DefaultTokenRateLimit = RateLimit{Requests: 60, Period: time.Minute}
+
+	// DefaultAPIRateLimit is the default limit of JSON API requests
+	DefaultAPIRateLimit = RateLimit{Requests: 60, Period: time.Minute}
+
+	// DefaultBlizzardRegionBudget is the default Blizzard API calls per region, below the 100 per second
+	// Blizzard allows while still letting a character lookup fetch all of its resources at once
+	DefaultBlizzardRegionBudget = RateLimit{Requests: 20, Period: time.Second}
+)
+
 // RedisConfig holds Redis-specific configuration
 type RedisConfig struct {
 	Addr     string
@@ -49,10 +238,20 @@ func Load() (*Config, error) {
 	// Get client ID and secret from environment variables
 	clientID := os.Getenv("CLIENT_ID")
 	clientSecret := os.Getenv("CLIENT_SECRET")
+	if clientID == "" || clientSecret == "" {
+		return nil, fmt.Errorf("missing required environment variables: CLIENT_ID or CLIENT_SECRET")
+	}
+
+	// Warcraftlogs needs client credentials, a static token or both
+	warcraftlogsClientID := os.Getenv("WARCRAFTLOGS_CLIENT_ID")
+	warcraftlogsClientSecret := os.Getenv("WARCRAFTLOGS_CLIENT_SECRET")
 	warcraftlogsAPIToken := os.Getenv("WARCRAFTLOGS_API_TOKEN")
 
-	if clientID == "" || clientSecret == "" || warcraftlogsAPIToken == "" {
-		return nil, fmt.Errorf("missing required environment variables: CLIENT_ID, CLIENT_SECRET or WARCRAFTLOGS_API_TOKEN")
+	if (warcraftlogsClientID == "") != (warcraftlogsClientSecret == "") {
+		return nil, fmt.Errorf("WARCRAFTLOGS_CLIENT_ID and WARCRAFTLOGS_CLIENT_SECRET must be set together")
+	}
+	if warcraftlogsClientID == "" && warcraftlogsAPIToken == "" {
+		return nil, fmt.Errorf("missing required environment variables: WARCRAFTLOGS_CLIENT_ID and WARCRAFTLOGS_CLIENT_SECRET, or WARCRAFTLOGS_API_TOKEN")
 	}
 
 	// Set default directories
@@ -104,13 +303,74 @@ func Load() (*Config, error) {
 		}
 	}
 
+	trustedProxies, err := parsePrefixes(os.Getenv("RATE_LIMIT_TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RATE_LIMIT_TRUSTED_PROXIES: %w", err)
+	}
+
 	return &Config{
-		Port:                 port,
-		ClientID:             clientID,
-		ClientSecret:         clientSecret,
-		TemplatesDir:         templatesDir,
-		AssetsDir:            assetsDir,
-		WarcraftlogsAPIToken: warcraftlogsAPIToken,
+		Port:                     port,
+		ClientID:                 clientID,
+		ClientSecret:             clientSecret,
+		TemplatesDir:             templatesDir,
+		AssetsDir:                assetsDir,
+		WarcraftlogsClientID:     warcraftlogsClientID,
+		WarcraftlogsClientSecret: warcraftlogsClientSecret,
+		WarcraftlogsAPIToken:     warcraftlogsAPIToken,
+		Upstream: UpstreamConfig{
+			BlizzardOAuthURL:     getEnv("BLIZZARD_OAUTH_URL", DefaultBlizzardOAuthURL),
+			BlizzardAPIURL:       getEnv("BLIZZARD_API_URL", DefaultBlizzardAPIURL),
+			WarcraftlogsOAuthURL: getEnv("WARCRAFTLOGS_OAUTH_URL", DefaultWarcraftlogsOAuthURL),
+			WarcraftlogsAPIURL:   getEnv("WARCRAFTLOGS_API_URL", DefaultWarcraftlogsAPIURL),
+			BlizzardRegionBudget: getRateLimit("BLIZZARD_REGION_BUDGET", DefaultBlizzardRegionBudget),
+		},
+		Server: ServerConfig{
+			ReadHeaderTimeout: getDuration("SERVER_READ_HEADER_TIMEOUT", DefaultReadHeaderTimeout),
+			ReadTimeout:       getDuration("SERVER_READ_TIMEOUT", DefaultReadTimeout),
+			WriteTimeout:      getDuration("SERVER_WRITE_TIMEOUT", DefaultWriteTimeout),
+			IdleTimeout:       getDuration("SERVER_IDLE_TIMEOUT", DefaultIdleTimeout),
+			ShutdownTimeout:   getDuration("SERVER_SHUTDOWN_TIMEOUT", DefaultShutdownTimeout),
+		},
+		Log: LogConfig{
+			Format: getEnv("LOG_FORMAT", "json"),
+			Level:  getEnv("LOG_LEVEL", "info"),
+		},
+		Cache: CacheConfig{
+			CharacterTTL:           getDuration("CACHE_CHARACTER_TTL", DefaultCharacterTTL),
+			CharacterRevalidateTTL: getDuration("CACHE_CHARACTER_REVALIDATE_TTL", DefaultCharacterRevalidateTTL),
+			GuildTTL:               getDuration("CACHE_GUILD_TTL", DefaultGuildTTL),
+			RankingsTTL:            getDuration("CACHE_RANKINGS_TTL", DefaultRankingsTTL),
+		},
+		Lookup: LookupConfig{
+			CharacterTimeout: getDuration("LOOKUP_CHARACTER_TIMEOUT", DefaultCharacterLookupTimeout),
+			GuildTimeout:     getDuration("LOOKUP_GUILD_TIMEOUT", DefaultGuildLookupTimeout),
+			TokenTimeout:     getDuration("LOOKUP_TOKEN_TIMEOUT", DefaultTokenLookupTimeout),
+		},
+		Warcraftlogs: WarcraftlogsConfig{
+			AttendanceWindow: getDuration("WARCRAFTLOGS_ATTENDANCE_WINDOW", DefaultAttendanceWindow),
+			PointsFloor:      getInt("WARCRAFTLOGS_POINTS_FLOOR", DefaultWarcraftlogsPointsFloor),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:        getEnv("RATE_LIMIT_ENABLED", "true") == "true",
+			TrustedProxies: trustedProxies,
+			Limits: map[string]RateLimit{
+				CharacterRateLimit: getRateLimit("RATE_LIMIT_CHARACTER", DefaultCharacterRateLimit),
+				GuildRateLimit:     getRateLimit("RATE_LIMIT_GUILD", DefaultGuildRateLimit),
+				ReportRateLimit:    getRateLimit("RATE_LIMIT_REPORT", DefaultReportRateLimit),
+				TokenRateLimit:     getRateLimit("RATE_LIMIT_TOKEN", DefaultTokenRateLimit),
+				APIRateLimit:       getRateLimit("RATE_LIMIT_API", DefaultAPIRateLimit),
+			},
+			Routes: map[string]string{
+				"/":              CharacterRateLimit,
+				"/character":     CharacterRateLimit,
+				"/guild-lookup":  GuildRateLimit,
+				"/guild":         GuildRateLimit,
+				"/guild-reports": ReportRateLimit,
+				"/report":        ReportRateLimit,
+				"/token":         TokenRateLimit,
+				"/api/v1/":       APIRateLimit,
+			},
+		},
 		Redis: RedisConfig{
 			Addr:     redisAddr,
 			Password: redisPassword,
@@ -118,3 +378,85 @@ func Load() (*Config, error) {
 		},
 	}, nil
 }
+
+// getEnv returns the value of an environment variable or the fallback if it is unset
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getDuration parses a duration such as 10m from an environment variable or returns the
+// fallback if it is unset or invalid
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		fmt.Printf("Warning: invalid duration %q for %s, using %s\n", value, key, fallback)
+		return fallback
+	}
+	return duration
+}
+
+// getInt parses a non-negative integer from an environment variable or returns the fallback if it is unset or invalid
+func getInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		fmt.Printf("Warning: invalid number %q for %s, using %d\n", value, key, fallback)
+		return fallback
+	}
+	return n
+}
+
+// getRateLimit parses a limit such as 20/1m from an environment variable or returns the
+// fallback if it is unset or invalid
+func getRateLimit(key string, fallback RateLimit) RateLimit {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	requests, period, _ := strings.Cut(value, "/")
+	limit := RateLimit{}
+	var err error
+	if limit.Requests, err = strconv.Atoi(requests); err == nil {
+		limit.Period, err = time.ParseDuration(period)
+	}
+	if err != nil || limit.Requests <= 0 || limit.Period <= 0 {
+		fmt.Printf("Warning: invalid rate limit %q for %s, using %d/%s\n", value, key, fallback.Requests, fallback.Period)
+		return fallback
+	}
+	return limit
+}
+
+// parsePrefixes parses a comma separated list of CIDRs and IP addresses
+func parsePrefixes(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		if addr, err := netip.ParseAddr(field); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(field)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
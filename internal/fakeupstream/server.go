@@ -0,0 +1,473 @@
+// Package fakeupstream provides an offline stand-in for the Blizzard and Warcraftlogs APIs.
+// Responses are served from the JSON fixtures embedded in the fixtures directory, so the
+// integration tests and local development can run without credentials or network access.
+package fakeupstream
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"wowarmory/internal/config"
+)
+
+const (
+	// ClientID is the only Blizzard client ID accepted by the fake OAuth endpoint
+	ClientID = "fake-client-id"
+
+	// ClientSecret is the only Blizzard client secret accepted by the fake OAuth endpoint
+	ClientSecret = "fake-client-secret"
+
+	// AccessToken is the Blizzard access token issued by the fake OAuth endpoint
+	AccessToken = "fake-access-token"
+
+	// WarcraftlogsToken is the static Warcraftlogs token accepted by the fake GraphQL endpoint
+	WarcraftlogsToken = "fake-warcraftlogs-token"
+
+	// WarcraftlogsClientID is the only Warcraftlogs client ID accepted by the fake OAuth endpoint
+	WarcraftlogsClientID = "fake-warcraftlogs-client-id"
+
+	// WarcraftlogsClientSecret is the only Warcraftlogs client secret accepted by the fake OAuth endpoint
+	WarcraftlogsClientSecret = "fake-warcraftlogs-client-secret"
+
+	// WarcraftlogsPointsPerHour is the Warcraftlogs points limit reported by the fake GraphQL endpoint
+	WarcraftlogsPointsPerHour = 3600
+
+	// WarcraftlogsPointsResetIn is the seconds until the points reset reported by the fake GraphQL endpoint
+	WarcraftlogsPointsResetIn = 1800
+)
+
+// FixturesModified is the Last-Modified time of every Blizzard fixture
+var FixturesModified = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// operationPattern extracts the operation name from a GraphQL query
+var operationPattern = regexp.MustCompile(`query\s+(\w+)`)
+
+// Handler serves the fake Blizzard and Warcraftlogs endpoints
+type Handler struct {
+	mux                       *http.ServeMux
+	tokenRequests             atomic.Int64
+	warcraftlogsTokenRequests atomic.Int64
+	warcraftlogsTokenRejected atomic.Int64
+
+	mu                      sync.Mutex
+	failures                []failure
+	pathFailures            map[string]int
+	warcraftlogsTokens      map[string]bool
+	warcraftlogsPointsSpent float64
+	tokenExpiresIn          int
+}
+
+// failure is an injected API failure
+type failure struct {
+	status     int
+	retryAfter string
+}
+
+// NewHandler creates a new fake upstream handler
+func NewHandler() *Handler {
+	h := &Handler{
+		mux:                http.NewServeMux(),
+		warcraftlogsTokens: make(map[string]bool),
+		pathFailures:       make(map[string]int),
+		tokenExpiresIn:     86399,
+	}
+
+	h.mux.HandleFunc("POST /oauth/token", h.handleBlizzardToken)
+	h.mux.HandleFunc("GET /{region}/profile/wow/character/{realm}/{name}", h.handleCharacter)
+	h.mux.HandleFunc("GET /{region}/profile/wow/character/{realm}/{name}/{resource...}", h.handleCharacter)
+	h.mux.HandleFunc("GET /{region}/data/wow/token/index", h.handleTokenPrice)
+	h.mux.HandleFunc("GET /{region}/data/wow/guild/{realm}/{name}/roster", h.handleGuildRoster)
+	h.mux.HandleFunc("GET /{region}/data/wow/mythic-keystone/season/index", h.handleMythicSeasonIndex)
+	h.mux.HandleFunc("POST /warcraftlogs/oauth/token", h.handleWarcraftlogsToken)
+	h.mux.HandleFunc("POST /warcraftlogs/api/v2/client", h.handleWarcraftlogs)
+
+	return h
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/oauth/token") {
+		if f, ok := h.nextFailure(); ok {
+			if f.retryAfter != "" {
+				w.Header().Set("Retry-After", f.retryAfter)
+			}
+			writeBlizzardError(w, f.status)
+			return
+		}
+		if status, ok := h.pathFailure(r.URL.Path); ok {
+			writeBlizzardError(w, status)
+			return
+		}
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// FailPath makes every API request to a path ending in suffix fail with the given status code,
+// a status code of 0 lets the requests through again
+func (h *Handler) FailPath(suffix string, status int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if status == 0 {
+		delete(h.pathFailures, suffix)
+		return
+	}
+	h.pathFailures[suffix] = status
+}
+
+// pathFailure returns the injected status code of a path
+func (h *Handler) pathFailure(path string) (int, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for suffix, status := range h.pathFailures {
+		if strings.HasSuffix(path, suffix) {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// FailNext makes the next n Blizzard and Warcraftlogs API requests fail with the given status code
+// and Retry-After header, an empty Retry-After is not sent. OAuth requests are not affected.
+func (h *Handler) FailNext(n, status int, retryAfter string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := 0; i < n; i++ {
+		h.failures = append(h.failures, failure{status: status, retryAfter: retryAfter})
+	}
+}
+
+// nextFailure pops the next injected failure
+func (h *Handler) nextFailure() (failure, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.failures) == 0 {
+		return failure{}, false
+	}
+	f := h.failures[0]
+	h.failures = h.failures[1:]
+	return f, true
+}
+
+// TokenRequests returns how many access tokens have been issued
+func (h *Handler) TokenRequests() int64 {
+	return h.tokenRequests.Load()
+}
+
+// SetTokenExpiresIn sets the expires_in of the Blizzard access tokens issued from now on, 0 leaves it out
+func (h *Handler) SetTokenExpiresIn(seconds int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tokenExpiresIn = seconds
+}
+
+// WarcraftlogsTokenRequests returns how many Warcraftlogs access tokens have been issued
+func (h *Handler) WarcraftlogsTokenRequests() int64 {
+	return h.warcraftlogsTokenRequests.Load()
+}
+
+// WarcraftlogsTokenRejections returns how many Warcraftlogs token requests were rejected
+func (h *Handler) WarcraftlogsTokenRejections() int64 {
+	return h.warcraftlogsTokenRejected.Load()
+}
+
+// RevokeWarcraftlogsTokens makes the GraphQL endpoint reject every Warcraftlogs access token issued so far,
+// the static token stays valid
+func (h *Handler) RevokeWarcraftlogsTokens() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	clear(h.warcraftlogsTokens)
+}
+
+// SetWarcraftlogsPointsSpent sets the points spent this hour reported with every Warcraftlogs query
+func (h *Handler) SetWarcraftlogsPointsSpent(points float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.warcraftlogsPointsSpent = points
+}
+
+// UpstreamConfig returns the upstream configuration pointing at a fake server with the given base URL
+func UpstreamConfig(baseURL string) config.UpstreamConfig {
+	return config.UpstreamConfig{
+		BlizzardOAuthURL:     baseURL + "/oauth/token",
+		BlizzardAPIURL:       baseURL + "/{region}",
+		WarcraftlogsOAuthURL: baseURL + "/warcraftlogs/oauth/token",
+		WarcraftlogsAPIURL:   baseURL + "/warcraftlogs/api/v2/client",
+	}
+}
+
+// Server is a fake upstream server for tests
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+// NewServer starts a new fake upstream server, callers must Close it when done
+func NewServer() *Server {
+	handler := NewHandler()
+	return &Server{
+		Server:  httptest.NewServer(handler),
+		Handler: handler,
+	}
+}
+
+// UpstreamConfig returns the upstream configuration pointing at this server
+func (s *Server) UpstreamConfig() config.UpstreamConfig {
+	return UpstreamConfig(s.URL)
+}
+
+// handleBlizzardToken issues an access token for the fake client credentials
+func (h *Handler) handleBlizzardToken(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != ClientID || clientSecret != ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{
+			"error":             "invalid_client",
+			"error_description": "Invalid client credentials",
+		})
+		return
+	}
+
+	h.tokenRequests.Add(1)
+	token := map[string]interface{}{
+		"access_token": AccessToken,
+		"token_type":   "bearer",
+	}
+	h.mu.Lock()
+	if h.tokenExpiresIn != 0 {
+		token["expires_in"] = h.tokenExpiresIn
+	}
+	h.mu.Unlock()
+	writeJSON(w, http.StatusOK, token)
+}
+
+// handleWarcraftlogsToken issues a new Warcraftlogs access token for the fake client credentials
+func (h *Handler) handleWarcraftlogsToken(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != WarcraftlogsClientID || clientSecret != WarcraftlogsClientSecret {
+		h.warcraftlogsTokenRejected.Add(1)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{
+			"error":   "invalid_client",
+			"message": "Client authentication failed",
+		})
+		return
+	}
+
+	token := fmt.Sprintf("fake-warcraftlogs-access-token-%d", h.warcraftlogsTokenRequests.Add(1))
+	h.mu.Lock()
+	h.warcraftlogsTokens[token] = true
+	h.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   31536000,
+	})
+}
+
+// handleCharacter serves a character profile resource from the fixtures
+func (h *Handler) handleCharacter(w http.ResponseWriter, r *http.Request) {
+	if !authorized(r, AccessToken) {
+		writeBlizzardError(w, http.StatusUnauthorized)
+		return
+	}
+
+	resource := r.PathValue("resource")
+	if resource == "" {
+		resource = "profile"
+	}
+
+	fixture := path.Join("fixtures/blizzard", r.PathValue("region"), "character",
+		strings.ToLower(r.PathValue("realm")), strings.ToLower(r.PathValue("name")), resource+".json")
+	h.serveBlizzardFixture(w, r, fixture)
+}
+
+// handleTokenPrice serves the WoW token price of a region from the fixtures
+func (h *Handler) handleTokenPrice(w http.ResponseWriter, r *http.Request) {
+	if !authorized(r, AccessToken) {
+		writeBlizzardError(w, http.StatusUnauthorized)
+		return
+	}
+
+	h.serveBlizzardFixture(w, r, path.Join("fixtures/blizzard", r.PathValue("region"), "token.json"))
+}
+
+// handleMythicSeasonIndex serves the Mythic+ seasons of a region from the fixtures
+func (h *Handler) handleMythicSeasonIndex(w http.ResponseWriter, r *http.Request) {
+	if !authorized(r, AccessToken) {
+		writeBlizzardError(w, http.StatusUnauthorized)
+		return
+	}
+
+	h.serveBlizzardFixture(w, r, path.Join("fixtures/blizzard", r.PathValue("region"), "mythic-keystone/season/index.json"))
+}
+
+// handleGuildRoster serves the roster of a guild from the fixtures
+func (h *Handler) handleGuildRoster(w http.ResponseWriter, r *http.Request) {
+	if !authorized(r, AccessToken) {
+		writeBlizzardError(w, http.StatusUnauthorized)
+		return
+	}
+
+	fixture := path.Join("fixtures/blizzard", r.PathValue("region"), "guild",
+		strings.ToLower(r.PathValue("realm")), strings.ToLower(r.PathValue("name")), "roster.json")
+	h.serveBlizzardFixture(w, r, fixture)
+}
+
+// serveBlizzardFixture writes a fixture file, or a Blizzard style 404 if it does not exist.
+// Like Blizzard it sets Last-Modified and answers If-Modified-Since with 304 Not Modified.
+func (h *Handler) serveBlizzardFixture(w http.ResponseWriter, r *http.Request, fixture string) {
+	data, err := fs.ReadFile(fixtures, fixture)
+	if err != nil {
+		writeBlizzardError(w, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	http.ServeContent(w, r, fixture, FixturesModified, bytes.NewReader(data))
+}
+
+// handleWarcraftlogs answers GraphQL queries with the fixture of the query operation.
+// Fixtures are looked up by the slug of the name or code variable and fall back to default.json.
+// Pages after the first of paginated queries are looked up as {slug}-{page}.json and report
+// tables as {code}-{fightID}-{dataType}.json. Queries asking for rateLimitData get the points budget
+// set with SetWarcraftlogsPointsSpent.
+func (h *Handler) handleWarcraftlogs(w http.ResponseWriter, r *http.Request) {
+	if !authorized(r, WarcraftlogsToken) && !h.warcraftlogsTokenIssued(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthenticated."})
+		return
+	}
+
+	var request struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeGraphQLError(w, "invalid request body")
+		return
+	}
+
+	match := operationPattern.FindStringSubmatch(request.Query)
+	if match == nil {
+		writeGraphQLError(w, "missing query operation name")
+		return
+	}
+	operation := match[1]
+
+	candidates := []string{"default.json"}
+	if name, ok := request.Variables["name"].(string); ok && name != "" {
+		fixture := slug(name)
+		if page, ok := request.Variables["page"].(float64); ok && page > 1 {
+			fixture = fmt.Sprintf("%s-%d", fixture, int(page))
+		}
+		candidates = append([]string{fixture + ".json"}, candidates...)
+	}
+	if code, ok := request.Variables["code"].(string); ok && code != "" {
+		fixture := slug(code)
+		if fightID, ok := request.Variables["fightID"].(float64); ok {
+			fixture = fmt.Sprintf("%s-%d", fixture, int(fightID))
+		}
+		if dataType, ok := request.Variables["dataType"].(string); ok {
+			fixture += "-" + slug(dataType)
+		}
+		candidates = append([]string{fixture + ".json"}, candidates...)
+	}
+
+	for _, candidate := range candidates {
+		data, err := fs.ReadFile(fixtures, path.Join("fixtures/warcraftlogs", operation, candidate))
+		if err == nil {
+			if strings.Contains(request.Query, "rateLimitData") {
+				data = h.withRateLimitData(data)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(data)
+			return
+		}
+	}
+
+	writeGraphQLError(w, fmt.Sprintf("no fixture for operation %s", operation))
+}
+
+// withRateLimitData adds the points budget to the data of a fixture, fixtures without data are left as they are
+func (h *Handler) withRateLimitData(fixture []byte) []byte {
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(fixture, &response); err != nil {
+		return fixture
+	}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(response["data"], &data); err != nil || data == nil {
+		return fixture
+	}
+
+	h.mu.Lock()
+	spent := h.warcraftlogsPointsSpent
+	h.mu.Unlock()
+
+	data["rateLimitData"], _ = json.Marshal(map[string]interface{}{
+		"limitPerHour":        WarcraftlogsPointsPerHour,
+		"pointsSpentThisHour": spent,
+		"pointsResetIn":       WarcraftlogsPointsResetIn,
+	})
+	response["data"], _ = json.Marshal(data)
+	result, err := json.Marshal(response)
+	if err != nil {
+		return fixture
+	}
+	return result
+}
+
+// authorized reports whether the request carries the expected bearer token
+func authorized(r *http.Request, token string) bool {
+	return r.Header.Get("Authorization") == "Bearer "+token
+}
+
+// warcraftlogsTokenIssued reports whether the request carries an unrevoked Warcraftlogs access token
+func (h *Handler) warcraftlogsTokenIssued(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.warcraftlogsTokens[token]
+}
+
+// slug converts a name into the form used for fixture file names
+func slug(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "-")
+}
+
+// writeBlizzardError writes an error in the format used by the Blizzard API
+func writeBlizzardError(w http.ResponseWriter, status int) {
+	writeJSON(w, status, map[string]interface{}{
+		"code":   status,
+		"type":   fmt.Sprintf("BLZWEBAPI%08d", status),
+		"detail": http.StatusText(status),
+	})
+}
+
+// writeGraphQLError writes a GraphQL error response
+func writeGraphQLError(w http.ResponseWriter, message string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"errors": []map[string]string{{"message": message}},
+	})
+}
+
+// writeJSON writes a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
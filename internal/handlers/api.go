@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"wowarmory/internal/api"
+	"wowarmory/internal/interfaces"
+	"wowarmory/internal/models"
+)
+
+// Error codes of the JSON API error body
+const (
+	ErrorCodeBadRequest           = "bad_request"
+	ErrorCodeNotFound             = "not_found"
+	ErrorCodeUpstream             = "upstream_error"
+	ErrorCodeUpstreamUnauthorized = "upstream_unauthorized"
+	ErrorCodeUpstreamRateLimited  = "upstream_rate_limited"
+	ErrorCodeUpstreamTimeout      = "upstream_timeout"
+	ErrorCodeInternal             = "internal_error"
+)
+
+// APIHandler serves the versioned JSON API that mirrors the HTML pages
+type APIHandler struct {
+	*BaseHandler
+	blizzardClient     interfaces.BlizzardAPI
+	warcraftlogsClient interfaces.WarcraftLogsAPI
+	tokenClient        interfaces.TokenAPI
+}
+
+// Ensure APIHandler implements Handler interface
+var _ interfaces.Handler = (*APIHandler)(nil)
+
+// APIError is the machine-readable error body returned by the JSON API
+type APIError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// GetName returns the name of the handler
+func (h *APIHandler) GetName() string {
+	return "APIHandler"
+}
+
+// NewAPIHandler creates a new APIHandler
+func NewAPIHandler(base *BaseHandler, blizzardClient interfaces.BlizzardAPI, warcraftlogsClient interfaces.WarcraftLogsAPI, tokenClient interfaces.TokenAPI) *APIHandler {
+	return &APIHandler{
+		BaseHandler:        base,
+		blizzardClient:     blizzardClient,
+		warcraftlogsClient: warcraftlogsClient,
+		tokenClient:        tokenClient,
+	}
+}
+
+// RegisterRoutes registers the handler's routes with the router
+func (h *APIHandler) RegisterRoutes(router interfaces.RouteRegistrar) {
+	router.HandleFunc("GET /api/v1/characters/{region}/{realm}/{name}", h.GetCharacter)
+	router.HandleFunc("GET /api/v1/guilds/{region}/{realm}/{name}", h.GetGuild)
+	router.HandleFunc("GET /api/v1/guilds/{region}/{realm}/{name}/reports", h.GetGuildReports)
+	router.HandleFunc("GET /api/v1/reports/{code}", h.GetReport)
+	router.HandleFunc("GET /api/v1/reports/{code}/fights/{fight}/{table}", h.GetReportTable)
+	router.HandleFunc("GET /api/v1/token", h.GetTokenPrices)
+	router.HandleFunc("GET /api/v1/recent-searches", h.GetRecentSearches)
+	router.HandleFunc("/api/", h.NotFound)
+}
+
+// GetCharacter returns a character as JSON
+func (h *APIHandler) GetCharacter(w http.ResponseWriter, r *http.Request) {
+	region := strings.ToLower(r.PathValue("region"))
+	realm := strings.ToLower(r.PathValue("realm"))
+	character := strings.ToLower(r.PathValue("name"))
+
+	refreshCharacter(r, h.blizzardClient, region, realm, character)
+
+	ctx, cancel := h.lookupContext(r, h.config.Lookup.CharacterTimeout)
+	defer cancel()
+
+	addParses := h.lookupParses(ctx, r, h.warcraftlogsClient, region, realm, character)
+
+	start := time.Now()
+	profileData, err := h.blizzardClient.GetCharacterProfile(ctx, region, realm, character)
+	h.logUpstream(r, h.blizzardClient, "GetCharacterProfile", start, err)
+	if err != nil {
+		writeLookupError(w, classifyError(err, "character", "Blizzard API"))
+		return
+	}
+
+	characterData, err := models.NewCharacterData(profileData, region)
+	if err != nil {
+		h.Logger(r).Error("failed to process character data", "error", err)
+		writeAPIError(w, http.StatusBadGateway, ErrorCodeUpstream, "invalid character response from the Blizzard API")
+		return
+	}
+	addParses(&characterData)
+
+	// Record the successful search in Redis
+	if err := h.RecordSearch(r, string(interfaces.CharacterSearchType), region, realm, character); err != nil {
+		// Error is already logged in RecordSearch
+		// Continue with the request
+	}
+
+	writeJSON(w, http.StatusOK, characterData)
+}
+
+// GetGuild returns a guild as JSON
+func (h *APIHandler) GetGuild(w http.ResponseWriter, r *http.Request) {
+	region := strings.ToLower(r.PathValue("region"))
+	realm := strings.ToLower(r.PathValue("realm"))
+	guild := strings.ToLower(r.PathValue("name"))
+
+	refreshGuild(r, h.warcraftlogsClient, guild, realm, region)
+
+	ctx, cancel := h.lookupContext(r, h.config.Lookup.GuildTimeout)
+	defer cancel()
+
+	addProgression := h.lookupProgression(ctx, r, h.warcraftlogsClient, region, realm, guild)
+	addRoster := h.lookupRoster(ctx, r, h.blizzardClient, region, realm, guild)
+
+	start := time.Now()
+	guildResponse, err := h.warcraftlogsClient.GetGuild(ctx, guild, realm, region)
+	h.logUpstream(r, h.warcraftlogsClient, "GetGuild", start, err)
+	if err != nil {
+		writeLookupError(w, classifyError(err, "guild", "Warcraftlogs API"))
+		return
+	}
+
+	guildData, err := models.NewGuildData(guildResponse, region, realm)
+	if err != nil {
+		h.Logger(r).Error("failed to process guild data", "error", err)
+		writeAPIError(w, http.StatusBadGateway, ErrorCodeUpstream, "invalid guild response from the Warcraftlogs API")
+		return
+	}
+	addProgression(guildData)
+	addRoster(guildData)
+
+	// Record the successful search in Redis
+	if err := h.RecordSearch(r, string(interfaces.GuildSearchType), region, realm, guild); err != nil {
+		// Error is already logged in RecordSearch
+		// Continue with the request
+	}
+
+	writeJSON(w, http.StatusOK, guildData)
+}
+
+// GetGuildReports returns a page of the reports of a guild as JSON, ?page= picks the page
+func (h *APIHandler) GetGuildReports(w http.ResponseWriter, r *http.Request) {
+	region := strings.ToLower(r.PathValue("region"))
+	realm := strings.ToLower(r.PathValue("realm"))
+	guild := strings.ToLower(r.PathValue("name"))
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+	ctx, cancel := h.lookupContext(r, h.config.Lookup.GuildTimeout)
+	defer cancel()
+
+	start := time.Now()
+	reportsResponse, err := h.warcraftlogsClient.GetGuildReports(ctx, guild, realm, region, page)
+	h.logUpstream(r, h.warcraftlogsClient, "GetGuildReports", start, err)
+	if err != nil {
+		writeLookupError(w, classifyError(err, "guild", "Warcraftlogs API"))
+		return
+	}
+
+	reports := models.NewGuildReports(reportsResponse, region, realm)
+	if reports == nil {
+		writeAPIError(w, http.StatusBadGateway, ErrorCodeUpstream, "invalid reports response from the Warcraftlogs API")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reports)
+}
+
+// GetReport returns a report with its boss pulls as JSON
+func (h *APIHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+
+	ctx, cancel := h.lookupContext(r, h.config.Lookup.GuildTimeout)
+	defer cancel()
+
+	start := time.Now()
+	reportResponse, err := h.warcraftlogsClient.GetReport(ctx, code)
+	h.logUpstream(r, h.warcraftlogsClient, "GetReport", start, err)
+	if err != nil {
+		writeLookupError(w, classifyError(err, "report", "Warcraftlogs API"))
+		return
+	}
+
+	report := models.NewReport(reportResponse)
+	if report == nil {
+		writeAPIError(w, http.StatusBadGateway, ErrorCodeUpstream, "invalid report response from the Warcraftlogs API")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// GetReportTable returns the damage done, healing, damage taken or deaths table of a fight of a report as JSON
+func (h *APIHandler) GetReportTable(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	dataType := r.PathValue("table")
+	fightID, err := strconv.Atoi(r.PathValue("fight"))
+	if err != nil || fightID <= 0 {
+		writeAPIError(w, http.StatusBadRequest, ErrorCodeBadRequest, "fight must be a fight ID of the report")
+		return
+	}
+	if !slices.Contains(api.TableDataTypes, dataType) {
+		writeAPIError(w, http.StatusBadRequest, ErrorCodeBadRequest, "table must be one of "+strings.Join(api.TableDataTypes, ", "))
+		return
+	}
+
+	ctx, cancel := h.lookupContext(r, h.config.Lookup.GuildTimeout)
+	defer cancel()
+
+	start := time.Now()
+	tableResponse, err := h.warcraftlogsClient.GetReportTable(ctx, code, fightID, dataType)
+	h.logUpstream(r, h.warcraftlogsClient, "GetReportTable", start, err)
+	if err != nil {
+		writeLookupError(w, classifyError(err, "fight", "Warcraftlogs API"))
+		return
+	}
+
+	table := models.NewReportTable(tableResponse)
+	if table == nil {
+		writeAPIError(w, http.StatusBadGateway, ErrorCodeUpstream, "invalid table response from the Warcraftlogs API")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, table)
+}
+
+// GetTokenPrices returns the WoW token price of every region as JSON
+func (h *APIHandler) GetTokenPrices(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := h.lookupContext(r, h.config.Lookup.TokenTimeout)
+	defer cancel()
+
+	prices := []models.TokenPrice{}
+	for _, region := range []string{"eu", "us"} {
+		price, err := h.tokenClient.GetTokenPrice(ctx, region)
+		if err != nil {
+			h.Logger(r).Error("failed to get token price", "region", region, "error", err)
+			writeLookupError(w, classifyError(err, region+" token price", "Blizzard API"))
+			return
+		}
+		prices = append(prices, models.NewTokenPrice(region, price))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"prices": prices,
+	})
+}
+
+// GetRecentSearches returns the recent searches as JSON
+func (h *APIHandler) GetRecentSearches(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	searches, err := h.redisClient.GetRecentSearches(ctx)
+	if err != nil {
+		h.Logger(r).Error("failed to get recent searches", "error", err)
+		writeAPIError(w, http.StatusInternalServerError, ErrorCodeInternal, "failed to get recent searches")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"searches": searches,
+	})
+}
+
+// NotFound answers unknown API routes with a JSON error instead of the HTML pages
+func (h *APIHandler) NotFound(w http.ResponseWriter, r *http.Request) {
+	writeAPIError(w, http.StatusNotFound, ErrorCodeNotFound, "unknown API route")
+}
+
+// writeJSON writes a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("failed to encode JSON response", "error", err)
+	}
+}
+
+// writeLookupError writes the JSON API error of a failed upstream lookup
+func writeLookupError(w http.ResponseWriter, lookupErr lookupError) {
+	lookupErr.setRetryAfter(w)
+	writeAPIError(w, lookupErr.Status, lookupErr.Code, lookupErr.Message)
+}
+
+// writeAPIError writes a JSON API error body with the given status code
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]APIError{
+		"error": {
+			Status:  status,
+			Code:    code,
+			Message: message,
+		},
+	})
+}
@@ -2,24 +2,25 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"html/template"
+	"log/slog"
 	"net/http"
 	"time"
 	"wowarmory/internal/config"
-	"wowarmory/internal/redis"
+	"wowarmory/internal/interfaces"
+	"wowarmory/internal/logging"
 	"wowarmory/internal/templates"
 )
 
 // BaseHandler contains common handler functionality
 type BaseHandler struct {
 	config      *config.Config
-	redisClient *redis.Client
+	redisClient interfaces.SearchStore
 	templates   *templates.Manager
 }
 
 // NewBaseHandler creates a new base handler
-func NewBaseHandler(cfg *config.Config, redisClient *redis.Client, templateMgr *templates.Manager) *BaseHandler {
+func NewBaseHandler(cfg *config.Config, redisClient interfaces.SearchStore, templateMgr *templates.Manager) *BaseHandler {
 	return &BaseHandler{
 		config:      cfg,
 		redisClient: redisClient,
@@ -45,17 +46,77 @@ func (h *BaseHandler) RenderWithLayout(w http.ResponseWriter, contentTemplate st
 	return h.RenderTemplate(w, "master_layout.html", data)
 }
 
-// RenderError renders the error template
-func (h *BaseHandler) RenderError(w http.ResponseWriter, activeTab, url string) error {
-	layoutData := map[string]interface{}{
-		"PageTitle": "Error",
-		"ActiveTab": activeTab,
-		"url":       url,
-	}
-	w.WriteHeader(http.StatusNotFound)
+// upstreamSources are the upstream APIs the lookups of each tab use
+var upstreamSources = map[string]string{
+	"character": "Blizzard API",
+	"guild":     "Warcraftlogs API",
+	"report":    "Warcraftlogs API",
+	"token":     "Blizzard API",
+}
+
+// RenderError renders the error template for a failed lookup, choosing the status code and
+// message by the kind of the error so outages are not reported as missing characters
+func (h *BaseHandler) RenderError(w http.ResponseWriter, activeTab, url string, err error) error {
+	layoutData := h.writeErrorHeader(w, activeTab, url, err)
 	return h.RenderWithLayout(w, "error", layoutData)
 }
 
+// RenderErrorPartial is like RenderError but renders the error template without the layout for htmx requests
+func (h *BaseHandler) RenderErrorPartial(w http.ResponseWriter, activeTab, url string, err error) error {
+	data := h.writeErrorHeader(w, activeTab, url, err)
+	return h.RenderTemplate(w, "error", data)
+}
+
+// writeErrorHeader writes the status code and headers of a failed lookup and returns the error template data
+func (h *BaseHandler) writeErrorHeader(w http.ResponseWriter, activeTab, url string, err error) map[string]interface{} {
+	source, ok := upstreamSources[activeTab]
+	if !ok {
+		source = "upstream API"
+	}
+	lookupErr := classifyError(err, activeTab, source)
+
+	w.Header().Set("Content-Type", "text/html")
+	lookupErr.setRetryAfter(w)
+	w.WriteHeader(lookupErr.Status)
+
+	return map[string]interface{}{
+		"PageTitle":   lookupErr.Title,
+		"ActiveTab":   activeTab,
+		"Title":       lookupErr.Title,
+		"Description": lookupErr.Description,
+		"NotFound":    lookupErr.Status == http.StatusNotFound,
+		"url":         url,
+	}
+}
+
+// Logger returns the logger of the request, tagged with its request ID
+func (h *BaseHandler) Logger(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context())
+}
+
+// logUpstream logs how long a lookup in an upstream API client took for the request
+func (h *BaseHandler) logUpstream(r *http.Request, client interfaces.APIClient, operation string, start time.Time, err error) {
+	logger := h.Logger(r).With(
+		"client", client.GetClientName(),
+		"operation", operation,
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	if err != nil {
+		logger.Warn("upstream lookup failed", "error", err)
+		return
+	}
+	logger.Info("upstream lookup")
+}
+
+// lookupContext returns the context of an upstream lookup for the request, which is cancelled when
+// the client goes away or after the timeout. A zero timeout does not limit the lookup.
+func (h *BaseHandler) lookupContext(r *http.Request, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}
+
 // RecordSearch records a search in Redis
 func (h *BaseHandler) RecordSearch(r *http.Request, searchType string, region, realm, name string) error {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
@@ -63,7 +124,72 @@ func (h *BaseHandler) RecordSearch(r *http.Request, searchType string, region, r
 
 	if err := h.redisClient.RecordSearch(ctx, searchType, region, realm, name); err != nil {
 		// Log the error but don't fail the request
-		fmt.Printf("Error recording search: %v\n", err)
+		logging.FromContext(r.Context()).Error("failed to record search", "error", err)
 	}
 	return nil
 }
+
+// wantsRefresh reports whether the request asks to bypass cached upstream responses with ?refresh=1
+func wantsRefresh(r *http.Request) bool {
+	return r.URL.Query().Get("refresh") == "1"
+}
+
+// refreshCharacter drops the cached character when the request asks for fresh data
+func refreshCharacter(r *http.Request, client interfaces.BlizzardAPI, region, realm, character string) {
+	cache, ok := client.(interfaces.CharacterCache)
+	if !ok || !wantsRefresh(r) {
+		return
+	}
+
+	if err := cache.InvalidateCharacter(r.Context(), region, realm, character); err != nil {
+		logging.FromContext(r.Context()).Error("failed to invalidate cached character", "error", err)
+	}
+}
+
+// refreshCharacterRankings drops the cached parses of a character when the request asks for fresh data
+func refreshCharacterRankings(r *http.Request, client interfaces.WarcraftLogsAPI, character, realm, region string) {
+	cache, ok := client.(interfaces.CharacterRankingsCache)
+	if !ok || !wantsRefresh(r) {
+		return
+	}
+
+	if err := cache.InvalidateCharacterRankings(r.Context(), character, realm, region); err != nil {
+		logging.FromContext(r.Context()).Error("failed to invalidate cached character rankings", "error", err)
+	}
+}
+
+// refreshGuildProgression drops the cached progression of a guild when the request asks for fresh data
+func refreshGuildProgression(r *http.Request, client interfaces.WarcraftLogsAPI, guild, realm, region string, zoneID, difficulty int) {
+	cache, ok := client.(interfaces.GuildProgressionCache)
+	if !ok || !wantsRefresh(r) {
+		return
+	}
+
+	if err := cache.InvalidateGuildProgression(r.Context(), guild, realm, region, zoneID, difficulty); err != nil {
+		logging.FromContext(r.Context()).Error("failed to invalidate cached guild progression", "error", err)
+	}
+}
+
+// refreshGuildRoster drops the cached roster of a guild when the request asks for fresh data
+func refreshGuildRoster(r *http.Request, client interfaces.BlizzardAPI, region, realm, guild string) {
+	cache, ok := client.(interfaces.GuildRosterCache)
+	if !ok || !wantsRefresh(r) {
+		return
+	}
+
+	if err := cache.InvalidateGuildRoster(r.Context(), region, realm, guild); err != nil {
+		logging.FromContext(r.Context()).Error("failed to invalidate cached guild roster", "error", err)
+	}
+}
+
+// refreshGuild drops the cached guild when the request asks for fresh data
+func refreshGuild(r *http.Request, client interfaces.WarcraftLogsAPI, guild, realm, region string) {
+	cache, ok := client.(interfaces.GuildCache)
+	if !ok || !wantsRefresh(r) {
+		return
+	}
+
+	if err := cache.InvalidateGuild(r.Context(), guild, realm, region); err != nil {
+		logging.FromContext(r.Context()).Error("failed to invalidate cached guild", "error", err)
+	}
+}
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
+	"wowarmory/internal/api"
 	"wowarmory/internal/interfaces"
 	"wowarmory/internal/models"
 )
@@ -11,7 +15,8 @@ import (
 // CharacterHandler handles character-related HTTP requests
 type CharacterHandler struct {
 	*BaseHandler
-	blizzardClient interfaces.BlizzardAPI
+	blizzardClient     interfaces.BlizzardAPI
+	warcraftlogsClient interfaces.WarcraftLogsAPI
 }
 
 // Ensure CharacterHandler implements Handler interface
@@ -23,10 +28,11 @@ func (h *CharacterHandler) GetName() string {
 }
 
 // NewCharacterHandler creates a new CharacterHandler
-func NewCharacterHandler(base *BaseHandler, blizzardClient interfaces.BlizzardAPI) *CharacterHandler {
+func NewCharacterHandler(base *BaseHandler, blizzardClient interfaces.BlizzardAPI, warcraftlogsClient interfaces.WarcraftLogsAPI) *CharacterHandler {
 	return &CharacterHandler{
-		BaseHandler:    base,
-		blizzardClient: blizzardClient,
+		BaseHandler:        base,
+		blizzardClient:     blizzardClient,
+		warcraftlogsClient: warcraftlogsClient,
 	}
 }
 
@@ -45,22 +51,22 @@ func (h *CharacterHandler) LookupCharacter(w http.ResponseWriter, r *http.Reques
 
 	// If all parameters are provided, display character data
 	if region != "" && realm != "" && character != "" {
-		// Get access token
-		accessToken, err := h.blizzardClient.GetAccessToken()
-		if err != nil {
-			http.Error(w, "Error getting access token: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+		ctx, cancel := h.lookupContext(r, h.config.Lookup.CharacterTimeout)
+		defer cancel()
+
+		refreshCharacter(r, h.blizzardClient, region, realm, character)
+		addParses := h.lookupParses(ctx, r, h.warcraftlogsClient, region, realm, character)
 
 		// Get character profile
-		profileData, err := h.blizzardClient.GetCharacterProfile(accessToken, region, realm, character)
+		start := time.Now()
+		profileData, err := h.blizzardClient.GetCharacterProfile(ctx, region, realm, character)
+		h.logUpstream(r, h.blizzardClient, "GetCharacterProfile", start, err)
 		if err != nil {
 			// Execute error template with master layout
 			url := fmt.Sprintf("https://worldofwarcraft.blizzard.com/en-gb/character/%s/%s/%s", region, realm, character)
-			if err := h.RenderError(w, "character", url); err != nil {
+			if err := h.RenderError(w, "character", url, err); err != nil {
 				http.Error(w, "Error executing template: "+err.Error(), http.StatusInternalServerError)
 			}
-			fmt.Printf("Error getting character profile: %v\n", err)
 			return
 		}
 
@@ -70,6 +76,7 @@ func (h *CharacterHandler) LookupCharacter(w http.ResponseWriter, r *http.Reques
 			http.Error(w, "Error processing character data: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
+		addParses(&characterData)
 
 		// Record the successful search in Redis
 		if err := h.RecordSearch(r, string(interfaces.CharacterSearchType), region, realm, character); err != nil {
@@ -97,6 +104,12 @@ func (h *CharacterHandler) LookupCharacter(w http.ResponseWriter, r *http.Reques
 			"Region":            characterData.Region,
 			"Realm":             characterData.Realm,
 			"MainRawImage":      characterData.MainRawImage,
+			"Equipment":         characterData.Equipment,
+			"MythicPlus":        characterData.MythicPlus,
+			"Raids":             characterData.Raids,
+			"PvP":               characterData.PvP,
+			"Parses":            characterData.Parses,
+			"MissingSections":   characterData.MissingSections,
 		}
 
 		// Execute character template with master layout
@@ -129,20 +142,21 @@ func (h *CharacterHandler) GetCharacterTemplate(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Get access token
-	accessToken, err := h.blizzardClient.GetAccessToken()
-	if err != nil {
-		http.Error(w, "Error getting access token: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
+	ctx, cancel := h.lookupContext(r, h.config.Lookup.CharacterTimeout)
+	defer cancel()
+
+	refreshCharacter(r, h.blizzardClient, region, realm, character)
+	addParses := h.lookupParses(ctx, r, h.warcraftlogsClient, region, realm, character)
 
 	// Get character profile
-	profileData, err := h.blizzardClient.GetCharacterProfile(accessToken, region, realm, character)
+	start := time.Now()
+	profileData, err := h.blizzardClient.GetCharacterProfile(ctx, region, realm, character)
+	h.logUpstream(r, h.blizzardClient, "GetCharacterProfile", start, err)
 	if err != nil {
 		url := fmt.Sprintf("https://worldofwarcraft.blizzard.com/en-gb/character/%s/%s/%s", region, realm, character)
-		h.RenderTemplate(w, "error.html", map[string]string{"url": url})
-		fmt.Printf("Error getting character profile: %v\n", err)
-		w.WriteHeader(http.StatusNotFound)
+		if err := h.RenderErrorPartial(w, "character", url, err); err != nil {
+			http.Error(w, "Error executing template: "+err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -152,6 +166,7 @@ func (h *CharacterHandler) GetCharacterTemplate(w http.ResponseWriter, r *http.R
 		http.Error(w, "Error processing character data: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	addParses(&data)
 
 	// Record the successful search in Redis
 	if err := h.RecordSearch(r, string(interfaces.CharacterSearchType), region, realm, character); err != nil {
@@ -165,3 +180,31 @@ func (h *CharacterHandler) GetCharacterTemplate(w http.ResponseWriter, r *http.R
 		return
 	}
 }
+
+// lookupParses starts looking up the Warcraftlogs parses of a character while its profile is fetched
+// and returns a function that waits for them and adds them to the character. Parses are optional,
+// characters without logs have none and other failures are reported as a missing section.
+func (h *BaseHandler) lookupParses(ctx context.Context, r *http.Request, client interfaces.WarcraftLogsAPI, region, realm, character string) func(data *models.CharacterData) {
+	refreshCharacterRankings(r, client, character, realm, region)
+
+	done := make(chan struct{})
+	var response interface{}
+	var err error
+	go func() {
+		defer close(done)
+		start := time.Now()
+		response, err = client.GetCharacterRankings(ctx, character, realm, region)
+		h.logUpstream(r, client, "GetCharacterRankings", start, err)
+	}()
+
+	return func(data *models.CharacterData) {
+		<-done
+		if err != nil {
+			if !errors.Is(err, api.ErrNotFound) {
+				data.MissingSections = append(data.MissingSections, models.ParsesSection)
+			}
+			return
+		}
+		data.Parses = models.NewCharacterRankings(response)
+	}
+}
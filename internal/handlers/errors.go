@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"wowarmory/internal/api"
+)
+
+// lookupError describes how a failed upstream lookup is reported to the user
+type lookupError struct {
+	// Status is the HTTP status code of the response
+	Status int
+	// Code is the JSON API error code
+	Code string
+	// Message is the short JSON API error message
+	Message string
+	// Title is the heading of the error page
+	Title string
+	// Description is the explanation on the error page
+	Description string
+	// RetryAfter is sent as Retry-After when the upstream API told us when to come back
+	RetryAfter time.Duration
+}
+
+// classifyError chooses the status code and messages of a failed lookup of a resource such as
+// "character" in an upstream source such as "Blizzard API", by the kind of the upstream error
+func classifyError(err error, resource, source string) lookupError {
+	switch {
+	case errors.Is(err, api.ErrNotFound):
+		return lookupError{
+			Status:      http.StatusNotFound,
+			Code:        ErrorCodeNotFound,
+			Message:     resource + " not found",
+			Title:       "Not Found",
+			Description: fmt.Sprintf("The requested %s could not be found.", resource),
+		}
+	case errors.Is(err, api.ErrRateLimited):
+		return lookupError{
+			Status:      http.StatusServiceUnavailable,
+			Code:        ErrorCodeUpstreamRateLimited,
+			Message:     fmt.Sprintf("the %s is rate limiting requests, try again later", source),
+			Title:       "Too Many Requests",
+			Description: fmt.Sprintf("The %s is receiving too many requests from us right now. Please try again in a moment.", source),
+			RetryAfter:  retryAfter(err),
+		}
+	case errors.Is(err, api.ErrTimeout):
+		return lookupError{
+			Status:      http.StatusGatewayTimeout,
+			Code:        ErrorCodeUpstreamTimeout,
+			Message:     fmt.Sprintf("the %s did not answer in time", source),
+			Title:       "Timed Out",
+			Description: fmt.Sprintf("The %s took too long to answer. Please try again.", source),
+		}
+	case errors.Is(err, api.ErrUnauthorized):
+		return lookupError{
+			Status:      http.StatusBadGateway,
+			Code:        ErrorCodeUpstreamUnauthorized,
+			Message:     fmt.Sprintf("the %s rejected our credentials", source),
+			Title:       "Service Unavailable",
+			Description: fmt.Sprintf("We could not sign in to the %s. Please try again later.", source),
+		}
+	case errors.Is(err, api.ErrUpstream):
+		return lookupError{
+			Status:      http.StatusBadGateway,
+			Code:        ErrorCodeUpstream,
+			Message:     fmt.Sprintf("failed to get %s from the %s", resource, source),
+			Title:       "Service Unavailable",
+			Description: fmt.Sprintf("The %s is not available right now. Please try again later.", source),
+		}
+	}
+	return lookupError{
+		Status:      http.StatusInternalServerError,
+		Code:        ErrorCodeInternal,
+		Message:     fmt.Sprintf("failed to get %s", resource),
+		Title:       "Something Went Wrong",
+		Description: fmt.Sprintf("The %s could not be loaded. Please try again later.", resource),
+	}
+}
+
+// setRetryAfter sets the Retry-After header of the response if the lookup error has one
+func (e lookupError) setRetryAfter(w http.ResponseWriter) {
+	if e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+}
+
+// retryAfter returns when the upstream API or the request budget allows calls again, 0 if unknown
+func retryAfter(err error) time.Duration {
+	var budgetErr *api.BudgetExhaustedError
+	if errors.As(err, &budgetErr) {
+		return budgetErr.RetryAfter
+	}
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
@@ -2,10 +2,13 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
+	"wowarmory/internal/api"
 	"wowarmory/internal/interfaces"
 	"wowarmory/internal/models"
 )
@@ -13,6 +16,7 @@ import (
 // GuildHandler handles guild-related HTTP requests
 type GuildHandler struct {
 	*BaseHandler
+	blizzardClient     interfaces.BlizzardAPI
 	warcraftlogsClient interfaces.WarcraftLogsAPI
 }
 
@@ -25,9 +29,10 @@ func (h *GuildHandler) GetName() string {
 }
 
 // NewGuildHandler creates a new GuildHandler
-func NewGuildHandler(base *BaseHandler, warcraftlogsClient interfaces.WarcraftLogsAPI) *GuildHandler {
+func NewGuildHandler(base *BaseHandler, blizzardClient interfaces.BlizzardAPI, warcraftlogsClient interfaces.WarcraftLogsAPI) *GuildHandler {
 	return &GuildHandler{
 		BaseHandler:        base,
+		blizzardClient:     blizzardClient,
 		warcraftlogsClient: warcraftlogsClient,
 	}
 }
@@ -47,19 +52,23 @@ func (h *GuildHandler) LookupGuild(w http.ResponseWriter, r *http.Request) {
 
 	// If all parameters are provided, display guild data
 	if region != "" && realm != "" && guild != "" {
-		// Create context with timeout
-		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		ctx, cancel := h.lookupContext(r, h.config.Lookup.GuildTimeout)
 		defer cancel()
 
+		refreshGuild(r, h.warcraftlogsClient, guild, realm, region)
+		addProgression := h.lookupProgression(ctx, r, h.warcraftlogsClient, region, realm, guild)
+		addRoster := h.lookupRoster(ctx, r, h.blizzardClient, region, realm, guild)
+
 		// Get guild data from Warcraftlogs API
+		start := time.Now()
 		guildResponse, err := h.warcraftlogsClient.GetGuild(ctx, guild, realm, region)
+		h.logUpstream(r, h.warcraftlogsClient, "GetGuild", start, err)
 		if err != nil {
 			// Execute error template with master layout
 			url := fmt.Sprintf("https://www.warcraftlogs.com/guild/%s/%s/%s", region, realm, guild)
-			if err := h.RenderError(w, "guild", url); err != nil {
+			if err := h.RenderError(w, "guild", url, err); err != nil {
 				http.Error(w, "Error executing template: "+err.Error(), http.StatusInternalServerError)
 			}
-			fmt.Printf("Error getting guild data: %v\n", err)
 			return
 		}
 
@@ -69,6 +78,8 @@ func (h *GuildHandler) LookupGuild(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Error processing guild data: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
+		addProgression(guildData)
+		addRoster(guildData)
 
 		// Record the successful search in Redis
 		if err := h.RecordSearch(r, string(interfaces.GuildSearchType), region, realm, guild); err != nil {
@@ -92,6 +103,13 @@ func (h *GuildHandler) LookupGuild(w http.ResponseWriter, r *http.Request) {
 			"WorldRank":       guildData.WorldRank,
 			"WorldRankColor":  guildData.WorldRankColor,
 			"Members":         guildData.Members,
+			"AttendanceRaids": guildData.AttendanceRaids,
+			"Attendance":      guildData.Attendance,
+			"Progression":     guildData.Progression,
+			"Roster":          guildData.Roster,
+			"RosterRanks":     guildData.RosterRanks(),
+			"RosterClasses":   guildData.RosterClasses(),
+			"MissingSections": guildData.MissingSections,
 		}
 
 		// Execute guild template with master layout
@@ -125,17 +143,22 @@ func (h *GuildHandler) GetGuildTemplate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	ctx, cancel := h.lookupContext(r, h.config.Lookup.GuildTimeout)
 	defer cancel()
 
+	refreshGuild(r, h.warcraftlogsClient, guild, realm, region)
+	addProgression := h.lookupProgression(ctx, r, h.warcraftlogsClient, region, realm, guild)
+	addRoster := h.lookupRoster(ctx, r, h.blizzardClient, region, realm, guild)
+
 	// Get guild data from Warcraftlogs API
+	start := time.Now()
 	guildResponse, err := h.warcraftlogsClient.GetGuild(ctx, guild, realm, region)
+	h.logUpstream(r, h.warcraftlogsClient, "GetGuild", start, err)
 	if err != nil {
 		url := fmt.Sprintf("https://www.warcraftlogs.com/guild/%s/%s/%s", region, realm, guild)
-		h.RenderTemplate(w, "error.html", map[string]string{"url": url})
-		fmt.Printf("Error getting guild data: %v\n", err)
-		w.WriteHeader(http.StatusNotFound)
+		if err := h.RenderErrorPartial(w, "guild", url, err); err != nil {
+			http.Error(w, "Error executing template: "+err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -145,6 +168,8 @@ func (h *GuildHandler) GetGuildTemplate(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Error processing guild data: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	addProgression(data)
+	addRoster(data)
 
 	// Record the successful search in Redis
 	if err := h.RecordSearch(r, string(interfaces.GuildSearchType), region, realm, guild); err != nil {
@@ -158,3 +183,68 @@ func (h *GuildHandler) GetGuildTemplate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 }
+
+// progressionParams returns the zone and difficulty the guild progression was requested for, zero when
+// they are missing or invalid so the defaults are used
+func progressionParams(r *http.Request) (zoneID, difficulty int) {
+	zoneID, _ = strconv.Atoi(r.URL.Query().Get("zone"))
+	difficulty, _ = strconv.Atoi(r.URL.Query().Get("difficulty"))
+	return max(zoneID, 0), max(difficulty, 0)
+}
+
+// lookupProgression starts looking up the raid progression of a guild while the guild is fetched and
+// returns a function that waits for it and adds it to the guild. Unknown zones and guilds without reports
+// have no progression, other failures are reported as a missing section.
+func (h *BaseHandler) lookupProgression(ctx context.Context, r *http.Request, client interfaces.WarcraftLogsAPI, region, realm, guild string) func(data *models.GuildData) {
+	zoneID, difficulty := progressionParams(r)
+	refreshGuildProgression(r, client, guild, realm, region, zoneID, difficulty)
+
+	done := make(chan struct{})
+	var response interface{}
+	var err error
+	go func() {
+		defer close(done)
+		start := time.Now()
+		response, err = client.GetGuildProgression(ctx, guild, realm, region, zoneID, difficulty)
+		h.logUpstream(r, client, "GetGuildProgression", start, err)
+	}()
+
+	return func(data *models.GuildData) {
+		<-done
+		if err != nil {
+			if !errors.Is(err, api.ErrNotFound) {
+				data.MissingSections = append(data.MissingSections, models.ProgressionSection)
+			}
+			return
+		}
+		data.Progression = models.NewGuildProgression(response)
+	}
+}
+
+// lookupRoster starts looking up the Blizzard roster of a guild while the guild is fetched and returns a
+// function that waits for it and merges it into the guild. Guilds unknown to Blizzard have no roster,
+// other failures are reported as a missing section.
+func (h *BaseHandler) lookupRoster(ctx context.Context, r *http.Request, client interfaces.BlizzardAPI, region, realm, guild string) func(data *models.GuildData) {
+	refreshGuildRoster(r, client, region, realm, guild)
+
+	done := make(chan struct{})
+	var response interface{}
+	var err error
+	go func() {
+		defer close(done)
+		start := time.Now()
+		response, err = client.GetGuildRoster(ctx, region, realm, guild)
+		h.logUpstream(r, client, "GetGuildRoster", start, err)
+	}()
+
+	return func(data *models.GuildData) {
+		<-done
+		if err != nil {
+			if !errors.Is(err, api.ErrNotFound) {
+				data.MissingSections = append(data.MissingSections, models.RosterSection)
+			}
+			return
+		}
+		data.AddRoster(response)
+	}
+}
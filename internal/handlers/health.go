@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+	"wowarmory/internal/interfaces"
+)
+
+// readinessCheckTimeout bounds each dependency check so a hanging dependency cannot stall /readyz
+const readinessCheckTimeout = 2 * time.Second
+
+// Dependency check statuses
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// HealthHandler serves the liveness and readiness endpoints
+type HealthHandler struct {
+	*BaseHandler
+	blizzardClient     interfaces.BlizzardAPI
+	warcraftlogsClient interfaces.WarcraftLogsAPI
+}
+
+// HealthResponse is the JSON body of the health endpoints
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// HealthCheck is the result of a single dependency check
+type HealthCheck struct {
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+	// Points is the Warcraftlogs points budget, only set on the warcraftlogs check
+	Points interface{} `json:"points,omitempty"`
+}
+
+// Ensure HealthHandler implements Handler interface
+var _ interfaces.Handler = (*HealthHandler)(nil)
+
+// GetName returns the name of the handler
+func (h *HealthHandler) GetName() string {
+	return "HealthHandler"
+}
+
+// NewHealthHandler creates a new HealthHandler
+func NewHealthHandler(base *BaseHandler, blizzardClient interfaces.BlizzardAPI, warcraftlogsClient interfaces.WarcraftLogsAPI) *HealthHandler {
+	return &HealthHandler{
+		BaseHandler:        base,
+		blizzardClient:     blizzardClient,
+		warcraftlogsClient: warcraftlogsClient,
+	}
+}
+
+// RegisterRoutes registers the handler's routes with the router
+func (h *HealthHandler) RegisterRoutes(router interfaces.RouteRegistrar) {
+	router.HandleFunc("GET /healthz", h.GetLiveness)
+	router.HandleFunc("GET /readyz", h.GetReadiness)
+}
+
+// GetLiveness reports that the process is up, it never checks dependencies
+func (h *HealthHandler) GetLiveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, HealthResponse{Status: HealthStatusOK})
+}
+
+// GetReadiness checks Redis, the Blizzard access token and WarcraftLogs concurrently,
+// responding with 503 when any of them is unavailable. The WarcraftLogs check includes the points budget.
+func (h *HealthHandler) GetReadiness(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]func(ctx context.Context) error{
+		"redis": h.redisClient.Ping,
+		"blizzard": func(ctx context.Context) error {
+			_, err := h.blizzardClient.GetAccessToken(ctx)
+			return err
+		},
+		"warcraftlogs": h.warcraftlogsClient.Ping,
+	}
+
+	response := HealthResponse{Status: HealthStatusOK, Checks: make(map[string]HealthCheck, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := runHealthCheck(r.Context(), check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			response.Checks[name] = result
+			if result.Status != HealthStatusOK {
+				response.Status = HealthStatusUnavailable
+				h.Logger(r).Warn("readiness check failed", "dependency", name, "error", result.Error)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The ping refreshes the points budget. A low budget only holds back expensive queries, it does not make the app unready.
+	warcraftlogs := response.Checks["warcraftlogs"]
+	warcraftlogs.Points = h.warcraftlogsClient.PointsStatus()
+	response.Checks["warcraftlogs"] = warcraftlogs
+
+	status := http.StatusOK
+	if response.Status != HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, response)
+}
+
+// runHealthCheck runs a check with readinessCheckTimeout. Checks that cannot be cancelled
+// are abandoned on timeout and finish in the background.
+func runHealthCheck(ctx context.Context, check func(ctx context.Context) error) HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := HealthCheck{Status: HealthStatusOK, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = HealthStatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"wowarmory/internal/interfaces"
+	"wowarmory/internal/metrics"
+)
+
+// MetricsHandler serves the Prometheus metrics
+type MetricsHandler struct {
+	*BaseHandler
+	metrics http.Handler
+}
+
+// Ensure MetricsHandler implements Handler interface
+var _ interfaces.Handler = (*MetricsHandler)(nil)
+
+// GetName returns the name of the handler
+func (h *MetricsHandler) GetName() string {
+	return "MetricsHandler"
+}
+
+// NewMetricsHandler creates a new MetricsHandler
+func NewMetricsHandler(base *BaseHandler) *MetricsHandler {
+	return &MetricsHandler{
+		BaseHandler: base,
+		metrics:     metrics.Handler(),
+	}
+}
+
+// RegisterRoutes registers the handler's routes with the router
+func (h *MetricsHandler) RegisterRoutes(router interfaces.RouteRegistrar) {
+	router.HandleFunc("GET /metrics", h.GetMetrics)
+}
+
+// GetMetrics serves the metrics in Prometheus text format
+func (h *MetricsHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	h.metrics.ServeHTTP(w, r)
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"wowarmory/internal/api"
+	"wowarmory/internal/interfaces"
+	"wowarmory/internal/models"
+)
+
+// ReportHandler handles the Warcraftlogs report browser of guilds
+type ReportHandler struct {
+	*BaseHandler
+	warcraftlogsClient interfaces.WarcraftLogsAPI
+}
+
+// Ensure ReportHandler implements Handler interface
+var _ interfaces.Handler = (*ReportHandler)(nil)
+
+// GetName returns the name of the handler
+func (h *ReportHandler) GetName() string {
+	return "ReportHandler"
+}
+
+// NewReportHandler creates a new ReportHandler
+func NewReportHandler(base *BaseHandler, warcraftlogsClient interfaces.WarcraftLogsAPI) *ReportHandler {
+	return &ReportHandler{
+		BaseHandler:        base,
+		warcraftlogsClient: warcraftlogsClient,
+	}
+}
+
+// RegisterRoutes registers the handler's routes with the router
+func (h *ReportHandler) RegisterRoutes(router interfaces.RouteRegistrar) {
+	router.HandleFunc("/guild-reports", h.ListReports)
+	router.HandleFunc("/report", h.ShowReport)
+}
+
+// ListReports handles the request for a page of the reports of a guild
+func (h *ReportHandler) ListReports(w http.ResponseWriter, r *http.Request) {
+	region := strings.ToLower(r.URL.Query().Get("region"))
+	realm := strings.ToLower(r.URL.Query().Get("realm"))
+	guild := strings.ToLower(r.URL.Query().Get("guild"))
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+	if region == "" || realm == "" || guild == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := h.lookupContext(r, h.config.Lookup.GuildTimeout)
+	defer cancel()
+
+	start := time.Now()
+	reportsResponse, err := h.warcraftlogsClient.GetGuildReports(ctx, guild, realm, region, page)
+	h.logUpstream(r, h.warcraftlogsClient, "GetGuildReports", start, err)
+	if err != nil {
+		url := fmt.Sprintf("https://www.warcraftlogs.com/guild/%s/%s/%s", region, realm, guild)
+		if err := h.RenderError(w, "guild", url, err); err != nil {
+			http.Error(w, "Error executing template: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	reports := models.NewGuildReports(reportsResponse, region, realm)
+	if reports == nil {
+		http.Error(w, "Error processing guild reports", http.StatusInternalServerError)
+		return
+	}
+
+	layoutData := map[string]interface{}{
+		"PageTitle":    reports.Guild + " Reports",
+		"ActiveTab":    "guild",
+		"Guild":        reports.Guild,
+		"Region":       reports.Region,
+		"Realm":        reports.Realm,
+		"Page":         reports.Page,
+		"PrevPage":     reports.Page - 1,
+		"NextPage":     reports.Page + 1,
+		"LastPage":     reports.LastPage,
+		"Total":        reports.Total,
+		"HasMorePages": reports.HasMorePages,
+		"Reports":      reports.Reports,
+	}
+
+	if err := h.RenderWithLayout(w, "guild_reports", layoutData); err != nil {
+		http.Error(w, "Error executing template: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ShowReport handles the request for a report with its boss pulls. With ?fight= it also shows the summary
+// table of the pull, ?table= picks damage done, healing, damage taken or deaths.
+func (h *ReportHandler) ShowReport(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+	fightID, _ := strconv.Atoi(r.URL.Query().Get("fight"))
+	dataType := r.URL.Query().Get("table")
+	if dataType == "" {
+		dataType = api.TableDamageDone
+	}
+	if !slices.Contains(api.TableDataTypes, dataType) {
+		http.Error(w, "Unknown table "+dataType, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := h.lookupContext(r, h.config.Lookup.GuildTimeout)
+	defer cancel()
+
+	start := time.Now()
+	reportResponse, err := h.warcraftlogsClient.GetReport(ctx, code)
+	h.logUpstream(r, h.warcraftlogsClient, "GetReport", start, err)
+	if err != nil {
+		url := fmt.Sprintf("https://www.warcraftlogs.com/reports/%s", code)
+		if err := h.RenderError(w, "report", url, err); err != nil {
+			http.Error(w, "Error executing template: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	report := models.NewReport(reportResponse)
+	if report == nil {
+		http.Error(w, "Error processing report", http.StatusInternalServerError)
+		return
+	}
+
+	layoutData := map[string]interface{}{
+		"PageTitle": report.Title,
+		"ActiveTab": "guild",
+		"Report":    report,
+		"DataTypes": api.TableDataTypes,
+		"FightID":   fightID,
+		"DataType":  dataType,
+	}
+
+	// The summary table of a fight is optional, the pulls are still useful without it
+	if fightID > 0 {
+		start := time.Now()
+		tableResponse, err := h.warcraftlogsClient.GetReportTable(ctx, code, fightID, dataType)
+		h.logUpstream(r, h.warcraftlogsClient, "GetReportTable", start, err)
+		switch {
+		case err == nil:
+			layoutData["Table"] = models.NewReportTable(tableResponse)
+		case !errors.Is(err, api.ErrNotFound):
+			layoutData["MissingSections"] = []string{models.TableSection}
+		}
+	}
+
+	if err := h.RenderWithLayout(w, "report", layoutData); err != nil {
+		http.Error(w, "Error executing template: "+err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"wowarmory/internal/interfaces"
+	"wowarmory/internal/models"
 )
 
 type TokenHandler struct {
@@ -30,30 +31,26 @@ func (h *TokenHandler) RegisterRoutes(router interfaces.RouteRegistrar) {
 }
 
 func (h *TokenHandler) GetTokenPrice(w http.ResponseWriter, r *http.Request) {
-	// Get access token
-	accessToken, err := h.tokenClient.GetAccessToken()
-	if err != nil {
-		http.Error(w, "Error getting access token: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
+	ctx, cancel := h.lookupContext(r, h.config.Lookup.TokenTimeout)
+	defer cancel()
 
 	// Get EU token price
-	euPrice, err := h.tokenClient.GetTokenPrice(accessToken, "eu")
+	euPrice, err := h.tokenClient.GetTokenPrice(ctx, "eu")
 	if err != nil {
-		http.Error(w, "Error getting EU token price: "+err.Error(), http.StatusInternalServerError)
+		h.renderTokenError(w, r, "eu", err)
 		return
 	}
 
 	// Get US token price
-	usPrice, err := h.tokenClient.GetTokenPrice(accessToken, "us")
+	usPrice, err := h.tokenClient.GetTokenPrice(ctx, "us")
 	if err != nil {
-		http.Error(w, "Error getting US token price: "+err.Error(), http.StatusInternalServerError)
+		h.renderTokenError(w, r, "us", err)
 		return
 	}
 
 	// Format prices (convert to gold)
-	euGold := euPrice / 10000
-	usGold := usPrice / 10000
+	euGold := models.NewTokenPrice("eu", euPrice).Gold
+	usGold := models.NewTokenPrice("us", usPrice).Gold
 
 	// Render token template with token prices
 	layoutData := map[string]interface{}{
@@ -68,3 +65,11 @@ func (h *TokenHandler) GetTokenPrice(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Error executing template: "+err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// renderTokenError renders a failed token price lookup with the status code of its kind, without the upstream error text
+func (h *TokenHandler) renderTokenError(w http.ResponseWriter, r *http.Request, region string, err error) {
+	h.Logger(r).Error("failed to get token price", "region", region, "error", err)
+	if err := h.RenderError(w, "token", "", err); err != nil {
+		http.Error(w, "Error executing template: "+err.Error(), http.StatusInternalServerError)
+	}
+}
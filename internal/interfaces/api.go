@@ -13,19 +13,71 @@ type APIClient interface {
 // BlizzardAPI defines the interface for Blizzard API operations
 type BlizzardAPI interface {
 	APIClient
-	GetAccessToken() (string, error)
-	GetCharacterProfile(accessToken, region, realm, character string) (map[string]interface{}, error)
+	// GetAccessToken returns the cached access token, refreshing it if needed
+	GetAccessToken(ctx context.Context) (string, error)
+	// GetCharacterProfile returns the character with all of its profile resources,
+	// recording which optional resources failed to load
+	GetCharacterProfile(ctx context.Context, region, realm, character string) (interface{}, error)
+	// CharacterModifiedSince reports whether the character profile changed after the given Last-Modified time
+	CharacterModifiedSince(ctx context.Context, region, realm, character, lastModified string) (bool, error)
+	// GetGuildRoster returns every member of a guild with their rank, level, class, race and faction
+	GetGuildRoster(ctx context.Context, region, realm, guild string) (interface{}, error)
 }
 
 // WarcraftLogsAPI defines the interface for WarcraftLogs API operations
 type WarcraftLogsAPI interface {
 	APIClient
 	GetGuild(ctx context.Context, name, serverSlug, serverRegion string) (interface{}, error)
+	// GetCharacterRankings returns the raid parses of a character in the current raid zone
+	GetCharacterRankings(ctx context.Context, name, serverSlug, serverRegion string) (interface{}, error)
+	// GetGuildProgression returns the boss pulls of a guild in a raid zone on a difficulty, zero picks the default
+	GetGuildProgression(ctx context.Context, name, serverSlug, serverRegion string, zoneID, difficulty int) (interface{}, error)
+	// GetGuildReports returns a page of the most recent reports of a guild, starting at page 1
+	GetGuildReports(ctx context.Context, name, serverSlug, serverRegion string, page int) (interface{}, error)
+	// GetReport returns a report with its boss pulls
+	GetReport(ctx context.Context, code string) (interface{}, error)
+	// GetReportTable returns the damage done, healing, damage taken or deaths table of a fight of a report
+	GetReportTable(ctx context.Context, code string, fightID int, dataType string) (interface{}, error)
+	// Ping checks that the API is reachable and accepts the access token
+	Ping(ctx context.Context) error
+	// PointsStatus returns the points budget of the current hour as last reported by the API
+	PointsStatus() interface{}
+}
+
+// CharacterCache is implemented by BlizzardAPI clients that cache characters
+type CharacterCache interface {
+	// InvalidateCharacter drops the cached character so the next lookup fetches it again
+	InvalidateCharacter(ctx context.Context, region, realm, character string) error
+}
+
+// GuildRosterCache is implemented by BlizzardAPI clients that cache guild rosters
+type GuildRosterCache interface {
+	// InvalidateGuildRoster drops the cached roster so the next lookup fetches it again
+	InvalidateGuildRoster(ctx context.Context, region, realm, guild string) error
+}
+
+// GuildCache is implemented by WarcraftLogsAPI clients that cache guilds
+type GuildCache interface {
+	// InvalidateGuild drops the cached guild so the next lookup fetches it again
+	InvalidateGuild(ctx context.Context, name, serverSlug, serverRegion string) error
+}
+
+// CharacterRankingsCache is implemented by WarcraftLogsAPI clients that cache character rankings
+type CharacterRankingsCache interface {
+	// InvalidateCharacterRankings drops the cached rankings so the next lookup fetches them again
+	InvalidateCharacterRankings(ctx context.Context, name, serverSlug, serverRegion string) error
+}
+
+// GuildProgressionCache is implemented by WarcraftLogsAPI clients that cache guild progression
+type GuildProgressionCache interface {
+	// InvalidateGuildProgression drops the cached progression so the next lookup fetches it again
+	InvalidateGuildProgression(ctx context.Context, name, serverSlug, serverRegion string, zoneID, difficulty int) error
 }
 
 // TokenAPI defines the interface for Token API operations
 type TokenAPI interface {
 	APIClient
-	GetAccessToken() (string, error)
-	GetTokenPrice(accessToken, region string) (float64, error)
+	// GetAccessToken returns the cached access token, refreshing it if needed
+	GetAccessToken(ctx context.Context) (string, error)
+	GetTokenPrice(ctx context.Context, region string) (float64, error)
 }
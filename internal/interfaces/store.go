@@ -2,8 +2,13 @@ package interfaces
 
 import (
 	"context"
+	"errors"
+	"time"
 )
 
+// ErrCacheMiss is returned by a CacheStore when a key is not cached
+var ErrCacheMiss = errors.New("cache miss")
+
 // SearchStore defines the interface for storing and retrieving search data
 type SearchStore interface {
 	// RecordSearch records a search in the store
@@ -12,10 +17,32 @@ type SearchStore interface {
 	// GetRecentSearches gets the most recent searches
 	GetRecentSearches(ctx context.Context) ([]SearchEntry, error)
 
+	// Ping checks that the store is reachable
+	Ping(ctx context.Context) error
+
 	// Close closes the connection to the store
 	Close() error
 }
 
+// CacheStore defines the interface for caching upstream API responses
+type CacheStore interface {
+	// GetCache gets a cached value, returning ErrCacheMiss if the key is not cached
+	GetCache(ctx context.Context, key string) ([]byte, error)
+
+	// SetCache caches a value for the given duration
+	SetCache(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// DeleteCache removes a cached value
+	DeleteCache(ctx context.Context, key string) error
+}
+
+// RateLimiter defines the interface for token bucket rate limits shared by all instances
+type RateLimiter interface {
+	// Allow takes a token from the bucket of key, which holds up to limit tokens and refills them
+	// over period. When the bucket is empty it returns false and how long until the next token.
+	Allow(ctx context.Context, key string, limit int, period time.Duration) (bool, time.Duration, error)
+}
+
 // SearchType represents the type of search
 type SearchType string
 
@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"net/http"
+	"time"
+)
+
+// Transport is an http.RoundTripper that logs every upstream call with the logger of the request context
+type Transport struct {
+	// Client is the name of the API client making the calls
+	Client string
+
+	// Base is the transport doing the actual requests, http.DefaultTransport if nil
+	Base http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	duration := time.Since(start)
+
+	// Only log the path, queries may carry user input and tokens
+	logger := FromContext(req.Context()).With(
+		"client", t.Client,
+		"method", req.Method,
+		"host", req.URL.Host,
+		"path", req.URL.Path,
+		"duration_ms", duration.Milliseconds(),
+	)
+	if err != nil {
+		logger.Warn("upstream call failed", "error", err)
+		return nil, err
+	}
+
+	logger.Info("upstream call", "status", resp.StatusCode)
+	return resp, nil
+}
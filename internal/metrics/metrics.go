@@ -0,0 +1,152 @@
+// Package metrics defines the Prometheus metrics of the application
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace is the prefix of every metric name
+const namespace = "wowarmory"
+
+// Cache lookup results
+const (
+	CacheHit         = "hit"
+	CacheMiss        = "miss"
+	CacheRevalidated = "revalidated"
+)
+
+// Registry holds every metric of the application
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	// HTTPRequests counts the handled HTTP requests by route pattern, method and status code
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by route pattern, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPRequestDuration observes the latency of HTTP requests by route pattern and method
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests, by route pattern and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	// UpstreamRequests counts the calls to upstream APIs by client, endpoint and status code
+	UpstreamRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_requests_total",
+		Help:      "Calls to upstream APIs, by client, endpoint and status code or error.",
+	}, []string{"client", "endpoint", "status"})
+
+	// UpstreamRequestDuration observes the latency of upstream API calls by client and endpoint
+	UpstreamRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Latency of calls to upstream APIs, by client and endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"client", "endpoint"})
+
+	// RedisCommandDuration observes the latency of Redis commands and pipelines
+	RedisCommandDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Latency of Redis commands and pipelines, by command and result.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command", "result"})
+
+	// CacheRequests counts response cache lookups by cache and result
+	CacheRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Response cache lookups, by cache and result (hit, miss or revalidated).",
+	}, []string{"cache", "result"})
+
+	// WarcraftlogsPointsLimit is the Warcraftlogs points the client may spend per hour
+	WarcraftlogsPointsLimit = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "warcraftlogs_points_limit",
+		Help:      "Warcraftlogs points the client may spend per hour, as last reported by the API.",
+	})
+
+	// WarcraftlogsPointsSpent is the Warcraftlogs points spent in the current hour
+	WarcraftlogsPointsSpent = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "warcraftlogs_points_spent",
+		Help:      "Warcraftlogs points spent in the current hour, as last reported by the API.",
+	})
+
+	// WarcraftlogsQueriesDegraded counts the expensive Warcraftlogs queries refused or cut short by query
+	WarcraftlogsQueriesDegraded = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "warcraftlogs_queries_degraded_total",
+		Help:      "Expensive Warcraftlogs queries refused or cut short because the points budget was below the floor, by query.",
+	}, []string{"query"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler returns the HTTP handler serving the metrics in Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTPRequest records a handled HTTP request
+func ObserveHTTPRequest(route, method string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	HTTPRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	HTTPRequestDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+// ObserveUpstreamRequest records a call to an upstream API, status is 0 when the call failed without a response
+func ObserveUpstreamRequest(client, endpoint string, status int, duration time.Duration) {
+	statusLabel := "error"
+	if status != 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+	UpstreamRequests.WithLabelValues(client, endpoint, statusLabel).Inc()
+	UpstreamRequestDuration.WithLabelValues(client, endpoint).Observe(duration.Seconds())
+}
+
+// ObserveRedisCommand records a Redis command or pipeline
+func ObserveRedisCommand(command string, err error, duration time.Duration) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	RedisCommandDuration.WithLabelValues(command, result).Observe(duration.Seconds())
+}
+
+// ObserveCache records a response cache lookup
+func ObserveCache(cache, result string) {
+	CacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// ObserveWarcraftlogsPoints records the Warcraftlogs points budget reported with a query
+func ObserveWarcraftlogsPoints(limit int, spent float64) {
+	WarcraftlogsPointsLimit.Set(float64(limit))
+	WarcraftlogsPointsSpent.Set(spent)
+}
+
+// ObserveWarcraftlogsDegraded records an expensive Warcraftlogs query refused or cut short
+func ObserveWarcraftlogsDegraded(query string) {
+	WarcraftlogsQueriesDegraded.WithLabelValues(query).Inc()
+}
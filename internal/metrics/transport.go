@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transport is an http.RoundTripper that records every upstream call in the upstream metrics
+type Transport struct {
+	// Client is the name of the API client making the calls
+	Client string
+
+	// Base is the transport doing the actual requests, http.DefaultTransport if nil
+	Base http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+
+	status := 0
+	if err == nil {
+		status = resp.StatusCode
+	}
+	ObserveUpstreamRequest(t.Client, Endpoint(req.URL.Path), status, time.Since(start))
+
+	return resp, err
+}
+
+// Endpoint converts an upstream URL path into a low cardinality endpoint label by dropping
+// realms, character and guild names, bracket names and numeric IDs, for example
+// /profile/wow/character/darkspear/tempests/pvp-bracket/3v3 becomes character/pvp-bracket.
+func Endpoint(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i+1] != "wow" {
+			continue
+		}
+
+		switch segments[i] {
+		case "profile":
+			// profile/wow/character/{realm}/{name}/{resource...}
+			if i+2 < len(segments) && segments[i+2] == "character" {
+				return joinEndpoint("character", segments[min(i+5, len(segments)):])
+			}
+			return joinEndpoint("profile", segments[i+2:])
+		case "data":
+			// data/wow/guild/{realm}/{name}/{resource...}
+			if i+2 < len(segments) && segments[i+2] == "guild" {
+				return joinEndpoint("guild", segments[min(i+5, len(segments)):])
+			}
+			return joinEndpoint("data", segments[i+2:])
+		}
+	}
+
+	if strings.HasSuffix(path, "/api/v2/client") {
+		return "graphql"
+	}
+	if len(segments) > 2 {
+		segments = segments[len(segments)-2:]
+	}
+	return strings.Join(segments, "/")
+}
+
+// joinEndpoint appends the resource segments to an endpoint prefix, dropping numeric IDs and
+// everything after a pvp-bracket since bracket names contain specializations
+func joinEndpoint(prefix string, resource []string) string {
+	endpoint := prefix
+	for _, segment := range resource {
+		if _, err := strconv.Atoi(segment); err == nil {
+			continue
+		}
+		endpoint += "/" + segment
+		if segment == "pvp-bracket" {
+			break
+		}
+	}
+	return endpoint
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+	"wowarmory/internal/config"
+	"wowarmory/internal/interfaces"
+	"wowarmory/internal/logging"
+)
+
+// ErrorCodeRateLimited is the JSON API error code of rate limited requests
+const ErrorCodeRateLimited = "rate_limited"
+
+// RateLimitMiddleware limits the requests per client IP with a token bucket per named limit, stored in
+// the limiter so the limit is shared by all machines. Routes with the same limit share the bucket, so a
+// full page and its htmx partial draw from the same quota. Limited requests get a 429 with Retry-After.
+// Paths without a configured limit are never limited, and requests are let through if the limiter fails.
+func RateLimitMiddleware(limiter interfaces.RateLimiter, cfg config.RateLimitConfig, next http.Handler) http.Handler {
+	if !cfg.Enabled || len(cfg.Routes) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := matchRateLimit(cfg.Routes, r)
+		limit, configured := cfg.Limits[name]
+		if !ok || !configured {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		client := rateLimitKey(ClientIP(r, cfg.TrustedProxies))
+		allowed, retryAfter, err := limiter.Allow(r.Context(), name+":"+client, limit.Requests, limit.Period)
+		if err != nil {
+			logging.FromContext(r.Context()).Warn("rate limiter unavailable, allowing request", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !allowed {
+			logging.FromContext(r.Context()).Info("rate limited", "limit", name, "path", r.URL.Path, "client_ip", client)
+			writeRateLimited(w, r, retryAfter)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientIP returns the IP of the client. Fly-Client-IP and X-Forwarded-For are only used when the
+// request comes from a trusted proxy, otherwise anyone could pick their own rate limit bucket.
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix) netip.Addr {
+	remote := parseAddr(r.RemoteAddr)
+	if !remote.IsValid() || !trusted(remote, trustedProxies) {
+		return remote
+	}
+
+	if client := parseAddr(r.Header.Get("Fly-Client-IP")); client.IsValid() {
+		return client
+	}
+
+	// Walk X-Forwarded-For from the right, the first address not added by a trusted proxy is the client
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := parseAddr(strings.TrimSpace(hops[i]))
+		if !hop.IsValid() {
+			break
+		}
+		client = hop
+		if !trusted(hop, trustedProxies) {
+			break
+		}
+	}
+	return client
+}
+
+// matchRateLimit returns the limit name of a request, exact paths win over the longest matching prefix.
+// The root is never used as a prefix, it would otherwise limit every static asset, and only limits
+// lookups with query parameters so loading the landing page is free.
+func matchRateLimit(routes map[string]string, r *http.Request) (string, bool) {
+	path := r.URL.Path
+	if path == "/" && r.URL.RawQuery == "" {
+		return "", false
+	}
+	if name, ok := routes[path]; ok {
+		return name, true
+	}
+
+	var route string
+	for prefix := range routes {
+		if prefix != "/" && strings.HasSuffix(prefix, "/") && strings.HasPrefix(path, prefix) && len(prefix) > len(route) {
+			route = prefix
+		}
+	}
+	name, ok := routes[route]
+	return name, ok
+}
+
+// rateLimitKey returns the bucket key of a client, IPv6 clients are limited per /64 since
+// a single client usually gets a whole /64
+func rateLimitKey(addr netip.Addr) string {
+	if addr.Is6() {
+		prefix, _ := addr.Prefix(64)
+		return prefix.String()
+	}
+	return addr.String()
+}
+
+// writeRateLimited writes the 429 response, as a JSON error for the JSON API
+func writeRateLimited(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+
+	message := "Too many requests, please try again later"
+	if !strings.HasPrefix(r.URL.Path, "/api/") {
+		http.Error(w, message, http.StatusTooManyRequests)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"status":  http.StatusTooManyRequests,
+			"code":    ErrorCodeRateLimited,
+			"message": message,
+		},
+	})
+}
+
+// trusted reports whether an address belongs to a trusted proxy
+func trusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAddr parses an IP address with or without a port, returning the zero Addr if it is invalid
+func parseAddr(s string) netip.Addr {
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap()
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"strings"
+	"wowarmory/internal/api"
+)
+
+// CharacterEquipment represents the processed equipment of a character for display
+type CharacterEquipment struct {
+	Items      []EquippedItem `json:"items"`
+	SetBonuses []SetBonus     `json:"set_bonuses"`
+}
+
+// EquippedItem represents a single equipped item for display
+type EquippedItem struct {
+	Slot         string       `json:"slot"`
+	Name         string       `json:"name"`
+	ItemLevel    int          `json:"item_level"`
+	Quality      string       `json:"quality"`
+	QualityClass string       `json:"-"`
+	Enchantments []string     `json:"enchantments"`
+	Sockets      []ItemSocket `json:"sockets"`
+}
+
+// ItemSocket represents a socket and the gem inside it, if any
+type ItemSocket struct {
+	Type string `json:"type"`
+	Gem  string `json:"gem"`
+}
+
+// SetBonus represents an equipped item set and its bonuses
+type SetBonus struct {
+	Name    string           `json:"name"`
+	Summary string           `json:"summary"`
+	Effects []SetBonusEffect `json:"effects"`
+}
+
+// SetBonusEffect represents a single bonus of an item set
+type SetBonusEffect struct {
+	Description   string `json:"description"`
+	RequiredCount int    `json:"required_count"`
+	Active        bool   `json:"active"`
+}
+
+// NewCharacterEquipment creates a new CharacterEquipment from the API response
+func NewCharacterEquipment(response *api.EquipmentResponse) *CharacterEquipment {
+	if response == nil {
+		return nil
+	}
+
+	equipment := &CharacterEquipment{}
+
+	for _, item := range response.EquippedItems {
+		equipped := EquippedItem{
+			Slot:         item.Slot.Name,
+			Name:         item.Name,
+			ItemLevel:    item.Level.Value,
+			Quality:      item.Quality.Name,
+			QualityClass: "quality-" + strings.ToLower(item.Quality.Type),
+		}
+
+		for _, enchantment := range item.Enchantments {
+			equipped.Enchantments = append(equipped.Enchantments, enchantmentName(enchantment.DisplayString))
+		}
+
+		for _, socket := range item.Sockets {
+			equipped.Sockets = append(equipped.Sockets, ItemSocket{
+				Type: socket.SocketType.Name,
+				Gem:  socket.Item.Name,
+			})
+		}
+
+		equipment.Items = append(equipment.Items, equipped)
+	}
+
+	for _, set := range response.EquippedItemSets {
+		bonus := SetBonus{
+			Name:    set.ItemSet.Name,
+			Summary: set.DisplayString,
+		}
+		for _, effect := range set.Effects {
+			bonus.Effects = append(bonus.Effects, SetBonusEffect{
+				Description:   effect.DisplayString,
+				RequiredCount: effect.RequiredCount,
+				Active:        effect.IsActive,
+			})
+		}
+		equipment.SetBonuses = append(equipment.SetBonuses, bonus)
+	}
+
+	return equipment
+}
+
+// enchantmentName strips the "Enchanted: " prefix the API adds to enchantment descriptions
+func enchantmentName(displayString string) string {
+	name := strings.TrimPrefix(displayString, "Enchanted: ")
+	// Crafted enchants embed a quality icon markup after the name
+	if i := strings.Index(name, " |A:"); i != -1 {
+		name = name[:i]
+	}
+	return name
+}
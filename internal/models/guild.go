@@ -2,35 +2,129 @@ package models
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 	"wowarmory/internal/api"
 )
 
+// RosterSection is the name of the guild roster in the missing sections of a guild
+const RosterSection = "Guild roster"
+
+// classNames are the names of the Blizzard playable class IDs, the roster only has the IDs
+var classNames = map[int]string{
+	1:  "Warrior",
+	2:  "Paladin",
+	3:  "Hunter",
+	4:  "Rogue",
+	5:  "Priest",
+	6:  "Death Knight",
+	7:  "Shaman",
+	8:  "Mage",
+	9:  "Warlock",
+	10: "Monk",
+	11: "Druid",
+	12: "Demon Hunter",
+	13: "Evoker",
+}
+
+// raceNames are the names of the Blizzard playable race IDs, the roster only has the IDs
+var raceNames = map[int]string{
+	1:  "Human",
+	2:  "Orc",
+	3:  "Dwarf",
+	4:  "Night Elf",
+	5:  "Undead",
+	6:  "Tauren",
+	7:  "Gnome",
+	8:  "Troll",
+	9:  "Goblin",
+	10: "Blood Elf",
+	11: "Draenei",
+	22: "Worgen",
+	24: "Pandaren",
+	25: "Pandaren",
+	26: "Pandaren",
+	27: "Nightborne",
+	28: "Highmountain Tauren",
+	29: "Void Elf",
+	30: "Lightforged Draenei",
+	31: "Zandalari Troll",
+	32: "Kul Tiran",
+	34: "Dark Iron Dwarf",
+	35: "Vulpera",
+	36: "Mag'har Orc",
+	37: "Mechagnome",
+	52: "Dracthyr",
+	70: "Dracthyr",
+	84: "Earthen",
+	85: "Earthen",
+}
+
 // GuildData represents the data for a guild
 type GuildData struct {
-	Name            string
-	Region          string
-	Realm           string
-	MemberCount     int
-	ServerRank      int
-	ServerRankColor string
-	RegionRank      int
-	RegionRankColor string
-	WorldRank       int
-	WorldRankColor  string
-	Members         []GuildMember
+	Name            string        `json:"name"`
+	Region          string        `json:"region"`
+	Realm           string        `json:"realm"`
+	MemberCount     int           `json:"member_count"`
+	ServerRank      int           `json:"server_rank"`
+	ServerRankColor string        `json:"server_rank_color"`
+	RegionRank      int           `json:"region_rank"`
+	RegionRankColor string        `json:"region_rank_color"`
+	WorldRank       int           `json:"world_rank"`
+	WorldRankColor  string        `json:"world_rank_color"`
+	Members         []GuildMember `json:"members"`
+	// AttendanceRaids is the number of raids the attendance was computed from
+	AttendanceRaids int `json:"attendance_raids"`
+	// AttendancePartial is true when the raids do not cover the whole attendance window
+	AttendancePartial bool               `json:"attendance_partial"`
+	Attendance        []PlayerAttendance `json:"attendance"`
+	Progression       *GuildProgression  `json:"progression"`
+	// Roster is every member of the guild from the Blizzard API, nil when the roster is unknown
+	Roster []RosterMember `json:"roster"`
+	// MissingSections lists the sections that could not be loaded from the API
+	MissingSections []string `json:"missing_sections"`
+}
+
+// PlayerAttendance represents how often a player attended the raids of the guild
+type PlayerAttendance struct {
+	Name     string    `json:"name"`
+	Class    string    `json:"class"`
+	Present  int       `json:"present"`
+	Benched  int       `json:"benched"`
+	Percent  int       `json:"percent"`
+	LastSeen time.Time `json:"last_seen"`
 }
 
-// GuildMember represents a member of a guild
+// GuildMember represents a raider of the most recent raid of a guild
 type GuildMember struct {
-	Name string
-	Type string
+	Name string `json:"name"`
+	// Type is the class of the raider
+	Type string `json:"type"`
+	// Rank is the guild rank of the raider, empty when the raider is not on the guild roster
+	Rank string `json:"rank,omitempty"`
+}
+
+// RosterMember represents a member of the guild roster with their Warcraftlogs attendance
+type RosterMember struct {
+	Name     string `json:"name"`
+	Rank     int    `json:"rank"`
+	RankName string `json:"rank_name"`
+	Level    int    `json:"level"`
+	Class    string `json:"class"`
+	Race     string `json:"race"`
+	Faction  string `json:"faction"`
+	// Raider is true when the member was in the most recent raid
+	Raider bool `json:"raider"`
+	// Attendance is the attendance percentage of the member, nil when they did not raid in the attendance window
+	Attendance *int `json:"attendance"`
 }
 
 // NewGuildData creates a new GuildData from the API response
 func NewGuildData(guildResponse interface{}, region, realm string) (*GuildData, error) {
 	// Type assertion for our known response structure
 	response, ok := guildResponse.(*api.GuildResponse)
-	if response.GuildData.Guild.Name != "" && ok {
+	if ok && response != nil && response.GuildData.Guild.Name != "" {
 		// We have a strongly typed response, use it directly
 		guildData := &GuildData{
 			Name:            response.GuildData.Guild.Name,
@@ -64,6 +158,10 @@ func NewGuildData(guildResponse interface{}, region, realm string) (*GuildData,
 			}
 		}
 
+		guildData.AttendanceRaids = len(response.GuildData.Guild.Attendance.Data)
+		guildData.AttendancePartial = response.Partial
+		guildData.Attendance = newPlayerAttendance(response.GuildData.Guild.Attendance.Data)
+
 		return guildData, nil
 	}
 
@@ -156,9 +254,10 @@ func NewGuildData(guildResponse interface{}, region, realm string) (*GuildData,
 							if ok && !memberMap[name] {
 								// Add player to the list if not already added
 								memberMap[name] = true
+								playerType, _ := playerMap["type"].(string)
 								guildMembers = append(guildMembers, GuildMember{
 									Name: name,
-									Type: "Raider", // Default type since it's not in the current API response
+									Type: playerType,
 								})
 							}
 						}
@@ -182,3 +281,142 @@ func NewGuildData(guildResponse interface{}, region, realm string) (*GuildData,
 		Members:         guildMembers,
 	}, nil
 }
+
+// AddRoster merges the Blizzard guild roster into the guild. Members get their Warcraftlogs attendance and
+// recent raiders get their rank. The roster is sorted by rank, then by name, and sets the member count.
+func (g *GuildData) AddRoster(rosterResponse interface{}) {
+	response, ok := rosterResponse.(*api.GuildRosterResponse)
+	if !ok || response == nil {
+		return
+	}
+
+	attendance := make(map[string]int, len(g.Attendance))
+	for _, player := range g.Attendance {
+		attendance[player.Name] = player.Percent
+	}
+	raiders := make(map[string]*GuildMember, len(g.Members))
+	for i := range g.Members {
+		raiders[g.Members[i].Name] = &g.Members[i]
+	}
+
+	g.Roster = make([]RosterMember, 0, len(response.Members))
+	for _, member := range response.Members {
+		character := member.Character
+		rosterMember := RosterMember{
+			Name:     character.Name,
+			Rank:     member.Rank,
+			RankName: rankName(member.Rank),
+			Level:    character.Level,
+			Class:    classNames[character.PlayableClass.ID],
+			Race:     raceNames[character.PlayableRace.ID],
+			Faction:  factionName(character.Faction.Type),
+		}
+		if rosterMember.Faction == "" {
+			rosterMember.Faction = response.Guild.Faction.Name
+		}
+		if percent, ok := attendance[character.Name]; ok {
+			rosterMember.Attendance = &percent
+		}
+		if raider, ok := raiders[character.Name]; ok {
+			rosterMember.Raider = true
+			raider.Rank = rosterMember.RankName
+			if raider.Type == "" {
+				raider.Type = rosterMember.Class
+			}
+		}
+		g.Roster = append(g.Roster, rosterMember)
+	}
+
+	sort.Slice(g.Roster, func(i, j int) bool {
+		if g.Roster[i].Rank != g.Roster[j].Rank {
+			return g.Roster[i].Rank < g.Roster[j].Rank
+		}
+		return g.Roster[i].Name < g.Roster[j].Name
+	})
+	g.MemberCount = len(g.Roster)
+}
+
+// RosterRanks returns the rank names of the roster, highest rank first
+func (g *GuildData) RosterRanks() []string {
+	var ranks []string
+	for _, member := range g.Roster {
+		if len(ranks) == 0 || ranks[len(ranks)-1] != member.RankName {
+			ranks = append(ranks, member.RankName)
+		}
+	}
+	return ranks
+}
+
+// RosterClasses returns the classes of the roster in alphabetical order
+func (g *GuildData) RosterClasses() []string {
+	seen := make(map[string]bool)
+	var classes []string
+	for _, member := range g.Roster {
+		if member.Class != "" && !seen[member.Class] {
+			seen[member.Class] = true
+			classes = append(classes, member.Class)
+		}
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// rankName returns the name of a guild rank. The roster only has rank numbers, rank 0 is always the guild master.
+func rankName(rank int) string {
+	if rank == 0 {
+		return "Guild Master"
+	}
+	return fmt.Sprintf("Rank %d", rank)
+}
+
+// factionName returns the name of a faction type such as HORDE
+func factionName(factionType string) string {
+	if factionType == "" {
+		return ""
+	}
+	return strings.ToUpper(factionType[:1]) + strings.ToLower(factionType[1:])
+}
+
+// newPlayerAttendance computes the attendance of every player in the raids, most reliable first.
+// Benched players are counted separately and do not raise the attendance percentage.
+func newPlayerAttendance(raids []api.AttendanceEntry) []PlayerAttendance {
+	players := make(map[string]*PlayerAttendance)
+	for _, raid := range raids {
+		// Count each player once per raid, present wins over benched
+		presence := make(map[string]api.GuildMember)
+		for _, player := range raid.Players {
+			if seen, ok := presence[player.Name]; !ok || seen.Presence != api.PresencePresent {
+				presence[player.Name] = player
+			}
+		}
+
+		for name, player := range presence {
+			attendance, ok := players[name]
+			if !ok {
+				attendance = &PlayerAttendance{Name: name, Class: player.Type}
+				players[name] = attendance
+			}
+			if player.Presence == api.PresenceBenched {
+				attendance.Benched++
+			} else {
+				attendance.Present++
+			}
+			if raid.Time().After(attendance.LastSeen) {
+				attendance.LastSeen = raid.Time()
+			}
+		}
+	}
+
+	attendance := make([]PlayerAttendance, 0, len(players))
+	for _, player := range players {
+		player.Percent = player.Present * 100 / len(raids)
+		attendance = append(attendance, *player)
+	}
+	sort.Slice(attendance, func(i, j int) bool {
+		if attendance[i].Present != attendance[j].Present {
+			return attendance[i].Present > attendance[j].Present
+		}
+		return attendance[i].Name < attendance[j].Name
+	})
+	return attendance
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"wowarmory/internal/api"
+)
+
+// MythicPlusData represents the processed Mythic+ data of a character for display
+type MythicPlusData struct {
+	Rating      int             `json:"rating"`
+	RatingColor string          `json:"rating_color"`
+	SeasonID    int             `json:"season_id"`
+	BestRuns    []MythicPlusRun `json:"best_runs"`
+}
+
+// MythicPlusRun represents the best run of a character in a single dungeon
+type MythicPlusRun struct {
+	Dungeon     string   `json:"dungeon"`
+	Level       int      `json:"level"`
+	Timed       bool     `json:"timed"`
+	Affixes     []string `json:"affixes"`
+	Duration    string   `json:"duration"`
+	Rating      int      `json:"rating"`
+	RatingColor string   `json:"rating_color"`
+	CompletedAt string   `json:"completed_at"`
+}
+
+// NewMythicPlusData creates a new MythicPlusData from the API response
+func NewMythicPlusData(response *api.MythicKeystoneResponse) *MythicPlusData {
+	if response == nil {
+		return nil
+	}
+
+	data := &MythicPlusData{
+		Rating:      int(response.Profile.CurrentMythicRating.Rating),
+		RatingColor: hexColor(response.Profile.CurrentMythicRating.Color),
+	}
+
+	if response.Season == nil {
+		return data
+	}
+	data.SeasonID = response.Season.Season.ID
+
+	// Keep only the best run per dungeon
+	bestRuns := make(map[int]api.MythicKeystoneRun)
+	for _, run := range response.Season.BestRuns {
+		best, ok := bestRuns[run.Dungeon.ID]
+		if !ok || run.MythicRating.Rating > best.MythicRating.Rating {
+			bestRuns[run.Dungeon.ID] = run
+		}
+	}
+
+	for _, run := range bestRuns {
+		affixes := make([]string, 0, len(run.KeystoneAffixes))
+		for _, affix := range run.KeystoneAffixes {
+			affixes = append(affixes, affix.Name)
+		}
+
+		data.BestRuns = append(data.BestRuns, MythicPlusRun{
+			Dungeon:     run.Dungeon.Name,
+			Level:       run.KeystoneLevel,
+			Timed:       run.IsCompletedWithinTime,
+			Affixes:     affixes,
+			Duration:    formatDuration(time.Duration(run.Duration) * time.Millisecond),
+			Rating:      int(run.MythicRating.Rating),
+			RatingColor: hexColor(run.MythicRating.Color),
+			CompletedAt: time.UnixMilli(run.CompletedTimestamp).UTC().Format("2006-01-02"),
+		})
+	}
+
+	// Show the highest keys first
+	sort.Slice(data.BestRuns, func(i, j int) bool {
+		if data.BestRuns[i].Level != data.BestRuns[j].Level {
+			return data.BestRuns[i].Level > data.BestRuns[j].Level
+		}
+		return data.BestRuns[i].Dungeon < data.BestRuns[j].Dungeon
+	})
+
+	return data
+}
+
+// hexColor converts an API color to a CSS hex color
+func hexColor(color api.RGBAColor) string {
+	return fmt.Sprintf("#%02x%02x%02x", color.R, color.G, color.B)
+}
+
+// formatDuration formats a duration as m:ss, or h:mm:ss for durations over an hour
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, seconds)
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"time"
+	"wowarmory/internal/api"
+)
+
+// ProgressionSection is the name of the guild progression in the missing sections of a guild
+const ProgressionSection = "Raid progression"
+
+// GuildProgression represents the progress of a guild on every boss of a raid zone on a single difficulty
+type GuildProgression struct {
+	ZoneID          int                 `json:"zone_id"`
+	Zone            string              `json:"zone"`
+	Difficulty      int                 `json:"difficulty"`
+	DifficultyName  string              `json:"difficulty_name"`
+	Killed          int                 `json:"killed"`
+	Total           int                 `json:"total"`
+	ServerRank      int                 `json:"server_rank"`
+	ServerRankColor string              `json:"server_rank_color"`
+	RegionRank      int                 `json:"region_rank"`
+	RegionRankColor string              `json:"region_rank_color"`
+	WorldRank       int                 `json:"world_rank"`
+	WorldRankColor  string              `json:"world_rank_color"`
+	Encounters      []EncounterProgress `json:"encounters"`
+	// Partial is true when older reports were not loaded, so first kills and pulls may be missing
+	Partial bool `json:"partial"`
+	// Zones and Difficulties are the choices of the zone selector
+	Zones        []ProgressionOption `json:"zones"`
+	Difficulties []ProgressionOption `json:"difficulties"`
+}
+
+// EncounterProgress represents the progress of a guild on a single boss
+type EncounterProgress struct {
+	Encounter string     `json:"encounter"`
+	Killed    bool       `json:"killed"`
+	FirstKill *time.Time `json:"first_kill,omitempty"`
+	Pulls     int        `json:"pulls"`
+	// BestPull is the lowest boss health left at the end of a pull in percent, 0 once killed
+	BestPull float64 `json:"best_pull"`
+}
+
+// ProgressionOption represents a zone or difficulty to choose from
+type ProgressionOption struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// NewGuildProgression creates a new GuildProgression from the API response, bosses in zone order
+func NewGuildProgression(progressionResponse interface{}) *GuildProgression {
+	response, ok := progressionResponse.(*api.GuildProgressionResponse)
+	if !ok || response == nil || response.GuildData.Guild == nil {
+		return nil
+	}
+
+	progress := response.GuildData.Guild.ZoneRanking.Progress
+	progression := &GuildProgression{
+		ZoneID:          response.Zone.ID,
+		Zone:            response.Zone.Name,
+		Difficulty:      response.Difficulty,
+		Total:           len(response.Zone.Encounters),
+		ServerRank:      progress.ServerRank.Number,
+		ServerRankColor: progress.ServerRank.Color,
+		RegionRank:      progress.RegionRank.Number,
+		RegionRankColor: progress.RegionRank.Color,
+		WorldRank:       progress.WorldRank.Number,
+		WorldRankColor:  progress.WorldRank.Color,
+		Partial:         response.Partial,
+	}
+
+	for _, zone := range response.Zones {
+		progression.Zones = append(progression.Zones, ProgressionOption{ID: zone.ID, Name: zone.Name})
+	}
+	for _, difficulty := range response.Zone.Difficulties {
+		progression.Difficulties = append(progression.Difficulties, ProgressionOption{ID: difficulty.ID, Name: difficulty.Name})
+		if difficulty.ID == response.Difficulty {
+			progression.DifficultyName = difficulty.Name
+		}
+	}
+
+	progression.Encounters = make([]EncounterProgress, len(response.Zone.Encounters))
+	encounters := make(map[int]*EncounterProgress, len(response.Zone.Encounters))
+	for i, encounter := range response.Zone.Encounters {
+		progression.Encounters[i] = EncounterProgress{Encounter: encounter.Name, BestPull: 100}
+		encounters[encounter.ID] = &progression.Encounters[i]
+	}
+
+	for _, report := range response.ReportData.Reports.Data {
+		for _, fight := range report.Fights {
+			encounter, ok := encounters[fight.EncounterID]
+			if !ok {
+				continue
+			}
+			encounter.Pulls++
+			if fight.Kill {
+				encounter.Killed = true
+				encounter.BestPull = 0
+				if killed := report.FightTime(fight); encounter.FirstKill == nil || killed.Before(*encounter.FirstKill) {
+					encounter.FirstKill = &killed
+				}
+			} else if fight.FightPercentage < encounter.BestPull {
+				encounter.BestPull = fight.FightPercentage
+			}
+		}
+	}
+
+	for _, encounter := range progression.Encounters {
+		if encounter.Killed {
+			progression.Killed++
+		}
+	}
+	return progression
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"wowarmory/internal/api"
+)
+
+// PvPData represents the processed PvP data of a character for display
+type PvPData struct {
+	HonorLevel     int          `json:"honor_level"`
+	HonorableKills int          `json:"honorable_kills"`
+	Brackets       []PvPBracket `json:"brackets"`
+}
+
+// PvPBracket represents the rating and season statistics of a single PvP bracket
+type PvPBracket struct {
+	Name    string `json:"name"`
+	Rating  int    `json:"rating"`
+	Played  int    `json:"played"`
+	Won     int    `json:"won"`
+	Lost    int    `json:"lost"`
+	WinRate int    `json:"win_rate"`
+}
+
+// bracketNames maps fixed bracket slugs to their display names
+var bracketNames = map[string]string{
+	"2v2": "2v2 Arena",
+	"3v3": "3v3 Arena",
+	"rbg": "Rated Battlegrounds",
+}
+
+// bracketOrder is the display order of the fixed brackets, spec brackets are listed after them
+var bracketOrder = map[string]int{
+	"2v2": 0,
+	"3v3": 1,
+	"rbg": 2,
+}
+
+// NewPvPData creates a new PvPData from the API response
+func NewPvPData(response *api.PvPResponse) *PvPData {
+	if response == nil {
+		return nil
+	}
+
+	data := &PvPData{
+		HonorLevel:     response.Summary.HonorLevel,
+		HonorableKills: response.Summary.HonorableKills,
+	}
+
+	brackets := make([]api.PvPBracket, len(response.Brackets))
+	copy(brackets, response.Brackets)
+	sort.SliceStable(brackets, func(i, j int) bool {
+		oi, iFixed := bracketOrder[brackets[i].Slug]
+		oj, jFixed := bracketOrder[brackets[j].Slug]
+		switch {
+		case iFixed && jFixed:
+			return oi < oj
+		case iFixed != jFixed:
+			return iFixed
+		default:
+			return brackets[i].Rating > brackets[j].Rating
+		}
+	})
+
+	for _, bracket := range brackets {
+		stats := bracket.SeasonMatchStatistics
+		winRate := 0
+		if stats.Played > 0 {
+			winRate = stats.Won * 100 / stats.Played
+		}
+
+		data.Brackets = append(data.Brackets, PvPBracket{
+			Name:    bracketName(bracket),
+			Rating:  bracket.Rating,
+			Played:  stats.Played,
+			Won:     stats.Won,
+			Lost:    stats.Lost,
+			WinRate: winRate,
+		})
+	}
+
+	return data
+}
+
+// bracketName returns the display name of a bracket, spec based brackets include the spec name
+func bracketName(bracket api.PvPBracket) string {
+	if name, ok := bracketNames[bracket.Slug]; ok {
+		return name
+	}
+
+	mode, spec, _ := strings.Cut(bracket.Slug, "-")
+	if bracket.Specialization.Name != "" {
+		spec = bracket.Specialization.Name
+	}
+
+	switch mode {
+	case "shuffle":
+		return fmt.Sprintf("Solo Shuffle (%s)", spec)
+	case "blitz":
+		return fmt.Sprintf("Battleground Blitz (%s)", spec)
+	default:
+		return bracket.Slug
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"wowarmory/internal/api"
+)
+
+// RaidProgression represents the raid progression of a character in the most recent expansion they raided in
+type RaidProgression struct {
+	// Expansion is the expansion last raided in, shown so old kills are not taken for current progression
+	Expansion string         `json:"expansion"`
+	Raids     []RaidProgress `json:"raids"`
+}
+
+// RaidProgress represents the progression of a character in a single raid instance
+type RaidProgress struct {
+	Name         string               `json:"name"`
+	Difficulties []DifficultyProgress `json:"difficulties"`
+}
+
+// DifficultyProgress represents the kills of a character in a raid on a single difficulty
+type DifficultyProgress struct {
+	Difficulty string `json:"difficulty"`
+	Completed  int    `json:"completed"`
+	Total      int    `json:"total"`
+	Summary    string `json:"summary"`
+}
+
+// difficultyAbbreviations maps API difficulty types to their common abbreviations
+var difficultyAbbreviations = map[string]string{
+	"LFR":    "LFR",
+	"NORMAL": "N",
+	"HEROIC": "H",
+	"MYTHIC": "M",
+}
+
+// NewRaidProgression creates a new RaidProgression from the API response
+func NewRaidProgression(response *api.RaidEncountersResponse) *RaidProgression {
+	if response == nil {
+		return nil
+	}
+
+	expansion := response.LatestExpansion()
+	if expansion == nil {
+		return nil
+	}
+
+	progression := &RaidProgression{
+		Expansion: expansion.Expansion.Name,
+	}
+
+	// Instances are listed oldest first, show the current raid first
+	for i := len(expansion.Instances) - 1; i >= 0; i-- {
+		instance := expansion.Instances[i]
+		raid := RaidProgress{
+			Name: instance.Instance.Name,
+		}
+
+		for _, mode := range instance.Modes {
+			abbreviation, ok := difficultyAbbreviations[mode.Difficulty.Type]
+			if !ok {
+				abbreviation = mode.Difficulty.Name
+			}
+
+			raid.Difficulties = append(raid.Difficulties, DifficultyProgress{
+				Difficulty: mode.Difficulty.Name,
+				Completed:  mode.Progress.CompletedCount,
+				Total:      mode.Progress.TotalCount,
+				Summary:    fmt.Sprintf("%d/%d %s", mode.Progress.CompletedCount, mode.Progress.TotalCount, abbreviation),
+			})
+		}
+
+		progression.Raids = append(progression.Raids, raid)
+	}
+
+	return progression
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"math"
+	"wowarmory/internal/api"
+)
+
+// CharacterRankings represents the Warcraftlogs parses of a character for display
+type CharacterRankings struct {
+	Difficulties []DifficultyRankings `json:"difficulties"`
+}
+
+// DifficultyRankings represents the parses of a character in the current raid zone on a single difficulty
+type DifficultyRankings struct {
+	Difficulty         string           `json:"difficulty"`
+	Metric             string           `json:"metric"`
+	BestAverage        int              `json:"best_average"`
+	BestAverageColor   string           `json:"best_average_color"`
+	MedianAverage      int              `json:"median_average"`
+	MedianAverageColor string           `json:"median_average_color"`
+	Encounters         []EncounterParse `json:"encounters"`
+}
+
+// EncounterParse represents the best and median parse of a character on a single boss
+type EncounterParse struct {
+	Encounter   string `json:"encounter"`
+	Spec        string `json:"spec"`
+	Killed      bool   `json:"killed"`
+	Kills       int    `json:"kills"`
+	Best        int    `json:"best"`
+	BestColor   string `json:"best_color"`
+	Median      int    `json:"median"`
+	MedianColor string `json:"median_color"`
+}
+
+// parseColors are the Warcraftlogs colors of parse percentiles, from the lowest percentile that earns them down
+var parseColors = []struct {
+	percentile int
+	color      string
+}{
+	{100, "#e5cc80"},
+	{99, "#e268a8"},
+	{95, "#ff8000"},
+	{75, "#a335ee"},
+	{50, "#0070ff"},
+	{25, "#1eff00"},
+	{0, "#666666"},
+}
+
+// NewCharacterRankings creates a new CharacterRankings from the API response, hardest difficulty first.
+// Difficulties without any kills are left out, nil is returned if the character has no kills at all.
+func NewCharacterRankings(rankingsResponse interface{}) *CharacterRankings {
+	response, ok := rankingsResponse.(*api.CharacterRankingsResponse)
+	if !ok || response == nil || response.CharacterData.Character == nil {
+		return nil
+	}
+
+	character := response.CharacterData.Character
+	rankings := &CharacterRankings{}
+	for _, difficulty := range []struct {
+		name     string
+		rankings api.ZoneRankings
+	}{
+		{"Mythic", character.Mythic},
+		{"Heroic", character.Heroic},
+		{"Normal", character.Normal},
+	} {
+		if data, ok := newDifficultyRankings(difficulty.name, difficulty.rankings); ok {
+			rankings.Difficulties = append(rankings.Difficulties, data)
+		}
+	}
+
+	if len(rankings.Difficulties) == 0 {
+		return nil
+	}
+	return rankings
+}
+
+// newDifficultyRankings converts the rankings of a single difficulty, reporting false if nothing was killed
+func newDifficultyRankings(name string, zone api.ZoneRankings) (DifficultyRankings, bool) {
+	data := DifficultyRankings{
+		Difficulty: name,
+		Metric:     zone.Metric,
+	}
+
+	killed := false
+	for _, ranking := range zone.Rankings {
+		parse := EncounterParse{
+			Encounter: ranking.Encounter.Name,
+			Spec:      ranking.Spec,
+			Kills:     ranking.TotalKills,
+		}
+		if ranking.RankPercent != nil {
+			killed = true
+			parse.Killed = true
+			parse.Best, parse.BestColor = percentile(*ranking.RankPercent)
+		}
+		if ranking.MedianPercent != nil {
+			parse.Median, parse.MedianColor = percentile(*ranking.MedianPercent)
+		}
+		data.Encounters = append(data.Encounters, parse)
+	}
+	if !killed {
+		return DifficultyRankings{}, false
+	}
+
+	if zone.BestPerformanceAverage != nil {
+		data.BestAverage, data.BestAverageColor = percentile(*zone.BestPerformanceAverage)
+	}
+	if zone.MedianPerformanceAverage != nil {
+		data.MedianAverage, data.MedianAverageColor = percentile(*zone.MedianPerformanceAverage)
+	}
+	return data, true
+}
+
+// percentile rounds a parse percentile down like Warcraftlogs does and returns it with its color
+func percentile(percent float64) (int, string) {
+	value := int(math.Floor(percent))
+	return value, ParseColor(value)
+}
+
+// ParseColor returns the Warcraftlogs color of a parse percentile
+func ParseColor(percentile int) string {
+	for _, parse := range parseColors {
+		if percentile >= parse.percentile {
+			return parse.color
+		}
+	}
+	return parseColors[len(parseColors)-1].color
+}
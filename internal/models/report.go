@@ -0,0 +1,238 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"wowarmory/internal/api"
+)
+
+// difficultyNames are the names of the Warcraftlogs raid difficulty IDs
+var difficultyNames = map[int]string{
+	1:                    "LFR",
+	api.DifficultyNormal: "Normal",
+	api.DifficultyHeroic: "Heroic",
+	api.DifficultyMythic: "Mythic",
+}
+
+// GuildReports represents a page of the report list of a guild
+type GuildReports struct {
+	Guild        string          `json:"guild"`
+	Region       string          `json:"region"`
+	Realm        string          `json:"realm"`
+	Page         int             `json:"page"`
+	LastPage     int             `json:"last_page"`
+	Total        int             `json:"total"`
+	HasMorePages bool            `json:"has_more_pages"`
+	Reports      []ReportSummary `json:"reports"`
+}
+
+// ReportSummary represents a report in the report list of a guild
+type ReportSummary struct {
+	Code     string    `json:"code"`
+	Title    string    `json:"title"`
+	Zone     string    `json:"zone"`
+	Owner    string    `json:"owner"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Duration string    `json:"duration"`
+}
+
+// Report represents a report with its boss pulls for display
+type Report struct {
+	ReportSummary
+	Guild  string        `json:"guild"`
+	Region string        `json:"region"`
+	Realm  string        `json:"realm"`
+	Kills  int           `json:"kills"`
+	Wipes  int           `json:"wipes"`
+	Fights []ReportFight `json:"fights"`
+}
+
+// ReportFight represents a boss pull of a report
+type ReportFight struct {
+	ID         int    `json:"id"`
+	Encounter  string `json:"encounter"`
+	Difficulty string `json:"difficulty"`
+	Kill       bool   `json:"kill"`
+	// BossPercentage is the health left on the boss at the end of the pull, 0 for kills
+	BossPercentage float64 `json:"boss_percentage"`
+	// Offset is when the pull started since the start of the report
+	Offset   string `json:"offset"`
+	Duration string `json:"duration"`
+}
+
+// NewGuildReports creates a new GuildReports from the API response
+func NewGuildReports(reportsResponse interface{}, region, realm string) *GuildReports {
+	response, ok := reportsResponse.(*api.GuildReportsResponse)
+	if !ok || response == nil || response.GuildData.Guild == nil {
+		return nil
+	}
+
+	page := response.ReportData.Reports
+	reports := &GuildReports{
+		Guild:        response.GuildData.Guild.Name,
+		Region:       region,
+		Realm:        realm,
+		Page:         page.CurrentPage,
+		LastPage:     page.LastPage,
+		Total:        page.Total,
+		HasMorePages: page.HasMorePages,
+		Reports:      []ReportSummary{},
+	}
+	for _, report := range page.Data {
+		reports.Reports = append(reports.Reports, newReportSummary(report))
+	}
+	return reports
+}
+
+// NewReport creates a new Report from the API response
+func NewReport(reportResponse interface{}) *Report {
+	response, ok := reportResponse.(*api.ReportResponse)
+	if !ok || response == nil || response.ReportData.Report == nil {
+		return nil
+	}
+
+	report := response.ReportData.Report
+	data := &Report{
+		ReportSummary: newReportSummary(report.ReportSummary),
+		Fights:        []ReportFight{},
+	}
+	if report.Guild != nil {
+		data.Guild = report.Guild.Name
+		data.Region = report.Guild.Server.Region.Slug
+		data.Realm = report.Guild.Server.Slug
+	}
+
+	for _, fight := range report.Fights {
+		pull := ReportFight{
+			ID:             fight.ID,
+			Encounter:      fight.Name,
+			Difficulty:     difficultyNames[fight.Difficulty],
+			Kill:           fight.Kill,
+			BossPercentage: fight.BossPercentage,
+			Offset:         formatDuration(time.Duration(fight.StartTime) * time.Millisecond),
+			Duration:       formatDuration(time.Duration(fight.EndTime-fight.StartTime) * time.Millisecond),
+		}
+		if fight.Kill {
+			pull.BossPercentage = 0
+			data.Kills++
+		} else {
+			data.Wipes++
+		}
+		data.Fights = append(data.Fights, pull)
+	}
+	return data
+}
+
+// newReportSummary converts a report of the API response
+func newReportSummary(report api.ReportSummary) ReportSummary {
+	summary := ReportSummary{
+		Code:     report.Code,
+		Title:    report.Title,
+		Start:    time.UnixMilli(report.StartTime),
+		End:      time.UnixMilli(report.EndTime),
+		Duration: formatDuration(time.Duration(report.EndTime-report.StartTime) * time.Millisecond),
+	}
+	if report.Zone != nil {
+		summary.Zone = report.Zone.Name
+	}
+	if report.Owner != nil {
+		summary.Owner = report.Owner.Name
+	}
+	return summary
+}
+
+// TableSection is the name of the report table in the missing sections of a report
+const TableSection = "Fight summary"
+
+// ReportTable represents the damage done, healing, damage taken or deaths of the players in a fight
+type ReportTable struct {
+	Code     string     `json:"code"`
+	FightID  int        `json:"fight_id"`
+	Fight    string     `json:"fight"`
+	DataType string     `json:"data_type"`
+	Duration string     `json:"duration"`
+	Rows     []TableRow `json:"rows"`
+}
+
+// TableRow represents a player in a report table. Deaths tables have a row per death with the time of
+// death since the start of the fight and the killing blow instead of an amount.
+type TableRow struct {
+	Player      string  `json:"player"`
+	Class       string  `json:"class"`
+	Spec        string  `json:"spec"`
+	Amount      float64 `json:"amount"`
+	PerSecond   float64 `json:"per_second"`
+	Percent     float64 `json:"percent"`
+	Time        string  `json:"time,omitempty"`
+	KillingBlow string  `json:"killing_blow,omitempty"`
+	// Elapsed is the time of death since the start of the fight, to sort deaths by
+	Elapsed time.Duration `json:"-"`
+}
+
+// NewReportTable creates a new ReportTable from the API response, the highest amounts or earliest deaths first
+func NewReportTable(tableResponse interface{}) *ReportTable {
+	response, ok := tableResponse.(*api.ReportTableResponse)
+	if !ok || response == nil || response.ReportData.Report == nil || len(response.ReportData.Report.Fights) == 0 {
+		return nil
+	}
+
+	report := response.ReportData.Report
+	fight := report.Fights[0]
+	table := &ReportTable{
+		Code:     report.Code,
+		FightID:  fight.ID,
+		Fight:    fight.Name,
+		DataType: response.DataType,
+		Duration: formatDuration(time.Duration(fight.EndTime-fight.StartTime) * time.Millisecond),
+		Rows:     []TableRow{},
+	}
+
+	var total float64
+	for _, entry := range report.Table.Data.Entries {
+		total += entry.Total
+	}
+	seconds := float64(report.Table.Data.TotalTime) / 1000
+
+	for _, entry := range report.Table.Data.Entries {
+		row := TableRow{
+			Player: entry.Name,
+			Class:  entry.Type,
+			Spec:   spec(entry.Icon),
+		}
+		if response.DataType == api.TableDeaths {
+			row.Elapsed = time.Duration(entry.Timestamp-fight.StartTime) * time.Millisecond
+			row.Time = formatDuration(row.Elapsed)
+			if entry.KillingBlow != nil {
+				row.KillingBlow = entry.KillingBlow.Name
+			}
+		} else {
+			row.Amount = entry.Total
+			if seconds > 0 {
+				row.PerSecond = math.Round(entry.Total/seconds*10) / 10
+			}
+			if total > 0 {
+				row.Percent = math.Round(entry.Total/total*1000) / 10
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	sort.SliceStable(table.Rows, func(i, j int) bool {
+		if response.DataType == api.TableDeaths {
+			return table.Rows[i].Elapsed < table.Rows[j].Elapsed
+		}
+		return table.Rows[i].Amount > table.Rows[j].Amount
+	})
+	return table
+}
+
+// spec returns the spec of a table entry icon such as Shaman-Elemental
+func spec(icon string) string {
+	if _, name, ok := strings.Cut(icon, "-"); ok {
+		return name
+	}
+	return ""
+}
@@ -0,0 +1,20 @@
+package models
+
+// copperPerGold is the number of copper in one gold
+const copperPerGold = 10000
+
+// TokenPrice represents the WoW token price of a region
+type TokenPrice struct {
+	Region string  `json:"region"`
+	Price  float64 `json:"price"`
+	Gold   float64 `json:"gold"`
+}
+
+// NewTokenPrice creates a new TokenPrice from a price in copper
+func NewTokenPrice(region string, price float64) TokenPrice {
+	return TokenPrice{
+		Region: region,
+		Price:  price,
+		Gold:   price / copperPerGold,
+	}
+}
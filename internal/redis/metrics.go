@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"context"
+	"net"
+	"time"
+	"wowarmory/internal/metrics"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// metricsHook records the latency of every Redis command and pipeline
+type metricsHook struct{}
+
+// DialHook implements the redis.Hook interface
+func (metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook implements the redis.Hook interface
+func (metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		metrics.ObserveRedisCommand(cmd.Name(), redisError(err), time.Since(start))
+		return err
+	}
+}
+
+// ProcessPipelineHook implements the redis.Hook interface
+func (metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		metrics.ObserveRedisCommand("pipeline", redisError(err), time.Since(start))
+		return err
+	}
+}
+
+// redisError ignores redis.Nil, a missing key is not a failed command
+func redisError(err error) error {
+	if err == redis.Nil {
+		return nil
+	}
+	return err
+}
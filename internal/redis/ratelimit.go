@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"wowarmory/internal/interfaces"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitKeyPrefix is the prefix of the rate limit bucket keys
+const RateLimitKeyPrefix = "ratelimit:"
+
+// Ensure Client implements RateLimiter interface
+var _ interfaces.RateLimiter = (*Client)(nil)
+
+// tokenBucketScript refills and takes a token from a bucket atomically. It uses the Redis clock
+// so all machines agree on the refill time. A full bucket is the same as a missing one, so the
+// key expires once it would have refilled completely.
+var tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(bucket[1]) or limit
+local updated = tonumber(bucket[2]) or now
+local rate = limit / period
+
+tokens = math.min(limit, tokens + math.max(0, now - updated) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated", now)
+redis.call("PEXPIRE", KEYS[1], period)
+return {allowed, retry}
+`)
+
+// Allow takes a token from the bucket of key, refilling limit tokens per period
+func (c *Client) Allow(ctx context.Context, key string, limit int, period time.Duration) (bool, time.Duration, error) {
+	result, err := tokenBucketScript.Run(ctx, c.rdb, []string{RateLimitKeyPrefix + key}, limit, period.Milliseconds()).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
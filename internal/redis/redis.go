@@ -29,8 +29,9 @@ type Client struct {
 	rdb *redis.Client
 }
 
-// Ensure Client implements SearchStore interface
+// Ensure Client implements SearchStore and CacheStore interfaces
 var _ interfaces.SearchStore = (*Client)(nil)
+var _ interfaces.CacheStore = (*Client)(nil)
 
 // SearchEntry represents a search entry
 type SearchEntry struct {
@@ -56,6 +57,8 @@ func NewClient(cfg *config.RedisConfig) (*Client, error) {
 		TLSConfig: tlsConfig,
 	})
 
+	rdb.AddHook(metricsHook{})
+
 	// Test the connection
 	ctx := context.Background()
 	if err := rdb.Ping(ctx).Err(); err != nil {
@@ -65,11 +68,47 @@ func NewClient(cfg *config.RedisConfig) (*Client, error) {
 	return &Client{rdb: rdb}, nil
 }
 
+// Ping checks that Redis is reachable
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.rdb.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping Redis: %w", err)
+	}
+	return nil
+}
+
 // Close closes the Redis client
 func (c *Client) Close() error {
 	return c.rdb.Close()
 }
 
+// GetCache gets a cached value from Redis
+func (c *Client) GetCache(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.rdb.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, interfaces.ErrCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached value: %w", err)
+	}
+	return value, nil
+}
+
+// SetCache stores a value in Redis that expires after the given duration
+func (c *Client) SetCache(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.rdb.Set(ctx, key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set cached value: %w", err)
+	}
+	return nil
+}
+
+// DeleteCache removes a cached value from Redis
+func (c *Client) DeleteCache(ctx context.Context, key string) error {
+	if err := c.rdb.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to delete cached value: %w", err)
+	}
+	return nil
+}
+
 // RecordSearch records a search in Redis
 func (c *Client) RecordSearch(ctx context.Context, searchType, region, realm, name string) error {
 	// Create a search entry
@@ -0,0 +1,192 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"wowarmory/internal/api"
+	"wowarmory/internal/config"
+	"wowarmory/internal/handlers"
+	"wowarmory/internal/interfaces"
+	"wowarmory/internal/router"
+)
+
+// memorySearchStore is an in-memory SearchStore so the API tests do not need Redis
+type memorySearchStore struct {
+	mu       sync.Mutex
+	searches []interfaces.SearchEntry
+}
+
+func (s *memorySearchStore) RecordSearch(ctx context.Context, searchType, region, realm, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.searches = append(s.searches, interfaces.SearchEntry{Type: searchType, Region: region, Realm: realm, Name: name})
+	return nil
+}
+
+func (s *memorySearchStore) GetRecentSearches(ctx context.Context) ([]interfaces.SearchEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]interfaces.SearchEntry{}, s.searches...), nil
+}
+
+func (s *memorySearchStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (s *memorySearchStore) Close() error {
+	return nil
+}
+
+// setupAPIServer starts the application router with the JSON API handler against the upstream
+func setupAPIServer(t *testing.T, u *upstream) *httptest.Server {
+	t.Helper()
+	return startAPIServer(t, &config.Config{AssetsDir: "../../assets"}, u, u.warcraftlogsClient(u.warcraftlogsToken))
+}
+
+// startAPIServer is like setupAPIServer with the given configuration and Warcraftlogs client
+func startAPIServer(t *testing.T, cfg *config.Config, u *upstream, warcraftlogsClient interfaces.WarcraftLogsAPI) *httptest.Server {
+	t.Helper()
+
+	tokens := u.tokenProvider(u.clientID, u.clientSecret)
+	base := handlers.NewBaseHandler(cfg, &memorySearchStore{}, nil)
+	apiHandler := handlers.NewAPIHandler(base,
+		api.NewBlizzardClient(u.config.BlizzardAPIURL, tokens, nil),
+		warcraftlogsClient,
+		api.NewTokenClient(u.config.BlizzardAPIURL, tokens, nil),
+	)
+
+	r := router.New(cfg)
+	r.SetupHandlers([]interfaces.Handler{apiHandler})
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// getJSON requests an API path and decodes the JSON body into v
+func getJSON(t *testing.T, server *httptest.Server, path string, v interface{}) int {
+	t.Helper()
+
+	resp, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatalf("Failed to request %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected JSON response for %s, got %q", path, contentType)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("Failed to decode %s: %v", path, err)
+	}
+	return resp.StatusCode
+}
+
+// TestJSONAPI tests the versioned JSON API endpoints
+func TestJSONAPI(t *testing.T) {
+	u := setupUpstream(t)
+	if u.clientID == "" || u.clientSecret == "" || u.warcraftlogsToken == "" {
+		t.Skip("Skipping test due to missing credentials")
+	}
+	server := setupAPIServer(t, u)
+
+	t.Run("Character", func(t *testing.T) {
+		var character map[string]interface{}
+		if status := getJSON(t, server, "/api/v1/characters/eu/darkspear/tempests", &character); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if character["name"] == "" || character["name"] == nil {
+			t.Errorf("Expected a character name, got %v", character)
+		}
+		if _, ok := character["realm"].(map[string]interface{}); !ok {
+			t.Errorf("Expected a realm object, got %v", character["realm"])
+		}
+	})
+
+	t.Run("CharacterNotFound", func(t *testing.T) {
+		var body map[string]handlers.APIError
+		if status := getJSON(t, server, "/api/v1/characters/eu/darkspear/nonexistentcharacter123", &body); status != http.StatusNotFound {
+			t.Fatalf("Expected status 404, got %d", status)
+		}
+		if body["error"].Code != handlers.ErrorCodeNotFound {
+			t.Errorf("Expected error code %q, got %q", handlers.ErrorCodeNotFound, body["error"].Code)
+		}
+	})
+
+	t.Run("Guild", func(t *testing.T) {
+		var guild map[string]interface{}
+		if status := getJSON(t, server, "/api/v1/guilds/eu/darkspear/Divine%20Intervention", &guild); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if guild["name"] == "" || guild["name"] == nil {
+			t.Errorf("Expected a guild name, got %v", guild)
+		}
+	})
+
+	t.Run("GuildNotFound", func(t *testing.T) {
+		var body map[string]handlers.APIError
+		if status := getJSON(t, server, "/api/v1/guilds/eu/darkspear/nonexistentguild123456789", &body); status != http.StatusNotFound {
+			t.Fatalf("Expected status 404, got %d", status)
+		}
+	})
+
+	t.Run("Token", func(t *testing.T) {
+		var body struct {
+			Prices []struct {
+				Region string  `json:"region"`
+				Gold   float64 `json:"gold"`
+			} `json:"prices"`
+		}
+		if status := getJSON(t, server, "/api/v1/token", &body); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if len(body.Prices) != 2 {
+			t.Fatalf("Expected 2 token prices, got %d", len(body.Prices))
+		}
+		for _, price := range body.Prices {
+			if price.Gold <= 0 {
+				t.Errorf("Expected a positive %s token price, got %f", price.Region, price.Gold)
+			}
+		}
+	})
+
+	t.Run("RecentSearches", func(t *testing.T) {
+		var body struct {
+			Searches []interfaces.SearchEntry `json:"searches"`
+		}
+		if status := getJSON(t, server, "/api/v1/recent-searches", &body); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if len(body.Searches) == 0 {
+			t.Error("Expected the earlier lookups to be recorded")
+		}
+	})
+
+	t.Run("UnknownRoute", func(t *testing.T) {
+		var body map[string]handlers.APIError
+		if status := getJSON(t, server, "/api/v1/unknown", &body); status != http.StatusNotFound {
+			t.Fatalf("Expected status 404, got %d", status)
+		}
+	})
+}
+
+// TestJSONAPIUpstreamFailure tests that rejected credentials are reported as 502
+func TestJSONAPIUpstreamFailure(t *testing.T) {
+	u := setupUpstream(t)
+
+	// Invalid credentials make every Blizzard request fail upstream
+	u.clientSecret = "invalid_client_secret"
+	server := setupAPIServer(t, u)
+
+	var body map[string]handlers.APIError
+	if status := getJSON(t, server, "/api/v1/characters/eu/darkspear/tempests", &body); status != http.StatusBadGateway {
+		t.Fatalf("Expected status 502, got %d", status)
+	}
+	if body["error"].Code != handlers.ErrorCodeUpstreamUnauthorized {
+		t.Errorf("Expected error code %q, got %q", handlers.ErrorCodeUpstreamUnauthorized, body["error"].Code)
+	}
+}
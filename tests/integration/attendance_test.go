@@ -0,0 +1,166 @@
+package integration
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"wowarmory/internal/api"
+	"wowarmory/internal/config"
+	"wowarmory/internal/models"
+)
+
+// TestGuildAttendance tests the paginated Warcraftlogs attendance of guilds
+func TestGuildAttendance(t *testing.T) {
+	u := setupUpstream(t)
+	if u.fake == nil {
+		t.Skip("Attendance is only checked against the fake upstream")
+	}
+
+	t.Run("Pages", testAttendancePages(u))
+	t.Run("PlayerAttendance", testPlayerAttendance(u))
+	t.Run("FirstPageOnly", testAttendanceFirstPageOnly(u))
+	t.Run("PageUnavailable", testAttendancePageUnavailable(u))
+	t.Run("GuildPage", testGuildPageAttendance(u))
+	t.Run("UnknownResponse", testGuildDataUnknownResponse)
+}
+
+// testGuildDataUnknownResponse tests that responses of another type are rejected instead of panicking
+func testGuildDataUnknownResponse(t *testing.T) {
+	for _, response := range []interface{}{"guild", (*api.GuildResponse)(nil), map[string]interface{}{}} {
+		if _, err := models.NewGuildData(response, "eu", "darkspear"); err == nil {
+			t.Errorf("Expected an error for %T, got none", response)
+		}
+	}
+}
+
+// getGuildResponse gets the divine intervention guild with the given client
+func getGuildResponse(t *testing.T, client *api.WarcraftlogsClient) *api.GuildResponse {
+	t.Helper()
+
+	guild, err := client.GetGuild(context.Background(), "divine intervention", "darkspear", "eu")
+	if err != nil {
+		t.Fatalf("Failed to get guild data: %v", err)
+	}
+	response, ok := guild.(*api.GuildResponse)
+	if !ok {
+		t.Fatalf("Failed to cast guild data to GuildResponse: %T", guild)
+	}
+	return response
+}
+
+// testAttendancePages tests that the pages are fetched until they pass the attendance window
+func testAttendancePages(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		response := getGuildResponse(t, u.warcraftlogsClient(u.warcraftlogsToken))
+
+		raids := response.GuildData.Guild.Attendance.Data
+		// Three raids on the first page, two of the second within eight weeks of the most recent raid
+		if len(raids) != 5 {
+			t.Fatalf("Expected 5 raids within the attendance window, got %d", len(raids))
+		}
+		if response.Partial {
+			t.Error("Expected the attendance to cover the whole window")
+		}
+		start := raids[0].Time().Add(-config.DefaultAttendanceWindow)
+		for _, raid := range raids {
+			if raid.Time().Before(start) {
+				t.Errorf("Expected raid %s to be trimmed, it started %s", raid.Code, raid.Time())
+			}
+		}
+	}
+}
+
+// testPlayerAttendance tests the attendance percentage, bench count and last seen date per player
+func testPlayerAttendance(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		response := getGuildResponse(t, u.warcraftlogsClient(u.warcraftlogsToken))
+
+		guild, err := models.NewGuildData(response, "eu", "darkspear")
+		if err != nil {
+			t.Fatalf("Failed to create guild data: %v", err)
+		}
+		if guild.AttendanceRaids != 5 {
+			t.Errorf("Expected attendance over 5 raids, got %d", guild.AttendanceRaids)
+		}
+		if len(guild.Members) != 5 {
+			t.Errorf("Expected the 5 raiders of the most recent raid, got %d", len(guild.Members))
+		}
+
+		attendance := make(map[string]models.PlayerAttendance)
+		for _, player := range guild.Attendance {
+			attendance[player.Name] = player
+		}
+		if _, ok := attendance["Oldguard"]; ok {
+			t.Error("Expected players only seen outside of the attendance window to be left out")
+		}
+		if len(guild.Attendance) == 0 || guild.Attendance[0].Name != "Tempests" {
+			t.Errorf("Expected Tempests to be the most reliable raider, got %+v", guild.Attendance)
+		}
+
+		kazgrim := attendance["Kazgrim"]
+		if kazgrim.Present != 3 || kazgrim.Benched != 1 || kazgrim.Percent != 60 {
+			t.Errorf("Expected Kazgrim present 3 times and benched once, got %+v", kazgrim)
+		}
+
+		thornvale := attendance["Thornvale"]
+		if thornvale.Percent != 20 || !thornvale.LastSeen.Equal(time.Date(2025, 1, 29, 19, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected Thornvale at 20%% last seen on 2025-01-29, got %+v", thornvale)
+		}
+	}
+}
+
+// testAttendanceFirstPageOnly tests that a zero attendance window only uses the first page
+func testAttendanceFirstPageOnly(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := api.NewWarcraftlogsClient(u.config.WarcraftlogsAPIURL, nil, u.warcraftlogsToken, config.WarcraftlogsConfig{})
+
+		response := getGuildResponse(t, client)
+		if raids := len(response.GuildData.Guild.Attendance.Data); raids != 3 {
+			t.Errorf("Expected the 3 raids of the first page, got %d", raids)
+		}
+	}
+}
+
+// testAttendancePageUnavailable tests that the raids of the first page are kept but flagged as partial when a later page fails
+func testAttendancePageUnavailable(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.failingWarcraftlogsClient(t, "GetGuildAttendance")
+
+		response := getGuildResponse(t, client)
+		if raids := len(response.GuildData.Guild.Attendance.Data); raids != 3 {
+			t.Errorf("Expected the 3 raids of the first page, got %d", raids)
+		}
+		if !response.Partial {
+			t.Error("Expected the attendance to be partial")
+		}
+	}
+}
+
+// testGuildPageAttendance tests that the guild page shows the attendance table
+func testGuildPageAttendance(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		server := setupPageServer(t, u, u.clientSecret)
+
+		resp, err := http.Get(server.URL + "/guild?region=eu&realm=darkspear&guild=divine+intervention")
+		if err != nil {
+			t.Fatalf("Failed to request the guild page: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read the guild page: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		for _, want := range []string{"Raid Attendance", "Kazgrim", "2025-01-29", "sortTable(this)"} {
+			if !strings.Contains(string(body), want) {
+				t.Errorf("Expected the guild page to contain %q", want)
+			}
+		}
+	}
+}
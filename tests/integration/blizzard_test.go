@@ -1,43 +1,43 @@
 package integration
 
 import (
-	"fmt"
-	"os"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
 	"testing"
 	"wowarmory/internal/api"
-
-	"github.com/joho/godotenv"
+	"wowarmory/internal/models"
 )
 
 // TestBlizzardAPIIntegration tests the integration with the Blizzard API
 func TestBlizzardAPIIntegration(t *testing.T) {
+	u := setupUpstream(t)
 
-	// Load .env file if it exists
-	err := godotenv.Load()
-	if err != nil {
-		fmt.Println("Warning: Error loading .env file, continuing without it. Ignore this if running as container.")
-	}
-	// Get Blizzard API credentials from environment variables
-	clientID := os.Getenv("CLIENT_ID")
-	clientSecret := os.Getenv("CLIENT_SECRET")
-
-	if clientID == "" || clientSecret == "" {
+	if u.clientID == "" || u.clientSecret == "" {
 		t.Fatal("CLIENT_ID and CLIENT_SECRET environment variables must be set")
 	}
 
-	t.Run("GetAccessToken", testGetAccessToken(clientID, clientSecret))
-	t.Run("GetCharacterProfile", testGetCharacterProfile(clientID, clientSecret))
+	t.Run("GetAccessToken", testGetAccessToken(u))
+	t.Run("AccessTokenWithoutLifetime", testAccessTokenWithoutLifetime(u))
+	t.Run("GetCharacterProfile", testGetCharacterProfile(u))
+	t.Run("GetCharacterSections", testGetCharacterSections(u))
+	t.Run("GetCharacterWithoutHistory", testGetCharacterWithoutHistory(u))
+	t.Run("GetCharacterPvPBracketFailed", testGetCharacterPvPBracketFailed(u))
+	t.Run("GetCharacterMythicSeason", testGetCharacterMythicSeason(u))
+	t.Run("CharacterPageRaids", testCharacterPageRaids(u))
 	t.Run("GetTokenPrice", TestGetTokenPrice)
 }
 
 // testGetAccessToken tests the GetAccessToken function
-func testGetAccessToken(clientID, clientSecret string) func(t *testing.T) {
+func testGetAccessToken(u *upstream) func(t *testing.T) {
 	return func(t *testing.T) {
 		// Create a new Blizzard API client
-		client := api.NewBlizzardClient(clientID, clientSecret)
+		client := u.blizzardClient(u.clientID, u.clientSecret)
 
 		// Get an access token
-		token, err := client.GetAccessToken()
+		token, err := client.GetAccessToken(context.Background())
 		if err != nil {
 			t.Fatalf("Failed to get access token: %v", err)
 		}
@@ -47,21 +47,50 @@ func testGetAccessToken(clientID, clientSecret string) func(t *testing.T) {
 			t.Fatal("Access token is empty")
 		}
 
+		// A second call should be served from the cache
+		cached, err := client.GetAccessToken(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to get cached access token: %v", err)
+		}
+		if cached != token {
+			t.Fatal("Expected cached access token to be reused")
+		}
+		if u.fake != nil && u.fake.TokenRequests() != 1 {
+			t.Fatalf("Expected 1 token request, got %d", u.fake.TokenRequests())
+		}
+
 		t.Logf("Successfully obtained access token: %s...", token[:10])
 	}
 }
 
-// testGetCharacterProfile tests the GetCharacterProfile function
-func testGetCharacterProfile(clientID, clientSecret string) func(t *testing.T) {
+// testAccessTokenWithoutLifetime tests that a token without expires_in is still cached
+func testAccessTokenWithoutLifetime(u *upstream) func(t *testing.T) {
 	return func(t *testing.T) {
-		// Create a new Blizzard API client
-		client := api.NewBlizzardClient(clientID, clientSecret)
+		if u.fake == nil {
+			t.Skip("Token lifetimes are only checked against the fake upstream")
+		}
+		u.fake.SetTokenExpiresIn(0)
+		t.Cleanup(func() { u.fake.SetTokenExpiresIn(86399) })
+
+		client := u.blizzardClient(u.clientID, u.clientSecret)
+		before := u.fake.TokenRequests()
+		for i := 0; i < 2; i++ {
+			if _, err := client.GetAccessToken(context.Background()); err != nil {
+				t.Fatalf("Failed to get access token: %v", err)
+			}
+		}
 
-		// Get an access token
-		token, err := client.GetAccessToken()
-		if err != nil {
-			t.Fatalf("Failed to get access token: %v", err)
+		if requests := u.fake.TokenRequests() - before; requests != 1 {
+			t.Errorf("Expected 1 token request, got %d", requests)
 		}
+	}
+}
+
+// testGetCharacterProfile tests the GetCharacterProfile function
+func testGetCharacterProfile(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		// Create a new Blizzard API client
+		client := u.blizzardClient(u.clientID, u.clientSecret)
 
 		// Test parameters - using a known character
 		region := "eu"
@@ -69,58 +98,243 @@ func testGetCharacterProfile(clientID, clientSecret string) func(t *testing.T) {
 		character := "tempests"
 
 		// Get character profile
-		profile, err := client.GetCharacterProfile(token, region, realm, character)
+		characterData, err := client.GetCharacterProfile(context.Background(), region, realm, character)
 		if err != nil {
 			t.Fatalf("Failed to get character profile: %v", err)
 		}
 
+		// Type assertion to access the data
+		response, ok := characterData.(*api.CharacterResponse)
+		if !ok {
+			t.Fatalf("Failed to cast character data to CharacterResponse: %T", characterData)
+		}
+
 		// Verify that the profile contains expected fields
-		requiredFields := []string{"name", "level", "average_item_level", "character_class"}
-		for _, field := range requiredFields {
-			if _, ok := profile[field]; !ok {
-				t.Errorf("Character profile missing required field: %s", field)
-			}
+		profile := response.Profile
+		if profile.Name == "" || profile.Level == 0 || profile.AverageItemLevel == 0 || profile.CharacterClass.Name == "" {
+			t.Errorf("Character profile missing required fields: %+v", profile)
 		}
 
 		// Log some basic character information
-		t.Logf("Character: %s", profile["name"])
-		if level, ok := profile["level"].(float64); ok {
-			t.Logf("Level: %.0f", level)
+		t.Logf("Character: %s", profile.Name)
+		t.Logf("Level: %d", profile.Level)
+		t.Logf("Item Level: %d", profile.AverageItemLevel)
+
+		if failed := response.FailedRequests(); len(failed) > 0 {
+			// The fake upstream has fixtures for every section
+			if u.fake != nil {
+				t.Errorf("Failed sub-requests: %v", failed)
+			}
+			t.Logf("Failed sub-requests: %v", failed)
+		}
+	}
+}
+
+// testGetCharacterSections tests the optional sections returned by GetCharacterProfile
+func testGetCharacterSections(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		// Create a new Blizzard API client
+		client := u.blizzardClient(u.clientID, u.clientSecret)
+
+		// Get character profile for a known character
+		characterData, err := client.GetCharacterProfile(context.Background(), "eu", "darkspear", "tempests")
+		if err != nil {
+			t.Fatalf("Failed to get character profile: %v", err)
+		}
+		response := characterData.(*api.CharacterResponse)
+
+		// Every section should either be loaded or recorded as failed
+		sections := map[string]bool{
+			api.CharacterMediaRequest:      response.Media != nil,
+			api.CharacterStatisticsRequest: response.Statistics != nil,
+			api.CharacterEquipmentRequest:  response.Equipment != nil,
+			api.CharacterMythicRequest:     response.MythicKeystone != nil,
+			api.CharacterRaidsRequest:      response.Raids != nil,
+			api.CharacterPvPRequest:        response.PvP != nil,
+		}
+		for name, loaded := range sections {
+			_, failed := response.Errors[name]
+			if loaded == failed {
+				t.Errorf("Section %s loaded: %t, failed: %t", name, loaded, failed)
+			}
+		}
+
+		if response.Equipment != nil {
+			for _, item := range response.Equipment.EquippedItems {
+				if item.Slot.Type == "" || item.Name == "" {
+					t.Errorf("Equipped item is missing slot or name: %+v", item)
+				}
+			}
+			t.Logf("Character has %d equipped items", len(response.Equipment.EquippedItems))
+		}
+
+		if response.MythicKeystone != nil {
+			t.Logf("Current mythic rating: %.1f", response.MythicKeystone.Profile.CurrentMythicRating.Rating)
+			if season := response.MythicKeystone.Season; season != nil {
+				t.Logf("Season %d has %d best runs", season.Season.ID, len(season.BestRuns))
+			}
 		}
-		if itemLevel, ok := profile["average_item_level"].(float64); ok {
-			t.Logf("Item Level: %.0f", itemLevel)
+
+		if response.Raids != nil {
+			if expansion := response.Raids.LatestExpansion(); expansion != nil {
+				t.Logf("Latest expansion raided %s has %d raid instances", expansion.Expansion.Name, len(expansion.Instances))
+			}
+		}
+
+		if response.PvP != nil {
+			t.Logf("Honor level: %d", response.PvP.Summary.HonorLevel)
+			for _, bracket := range response.PvP.Brackets {
+				t.Logf("Bracket %s: %d rating", bracket.Slug, bracket.Rating)
+			}
 		}
 	}
 }
 
-// TestGetTokenPrice tests the GetTokenPrice function
-func TestGetTokenPrice(t *testing.T) {
-	// Load .env file if it exists
-	err := godotenv.Load()
-	if err != nil {
-		fmt.Println("Warning: Error loading .env file, continuing without it. Ignore this if running as container.")
+// testGetCharacterWithoutHistory tests that sections Blizzard has no data for are not reported as missing
+func testGetCharacterWithoutHistory(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		if u.fake == nil {
+			t.Skip("Characters without Mythic+ and PvP history are only checked against the fake upstream")
+		}
+		client := u.blizzardClient(u.clientID, u.clientSecret)
+
+		// Tidecaller never ran a keystone or fought in PvP, Blizzard answers both with a 404
+		characterData, err := client.GetCharacterProfile(context.Background(), "eu", "darkspear", "tidecaller")
+		if err != nil {
+			t.Fatalf("Failed to get character profile: %v", err)
+		}
+		response := characterData.(*api.CharacterResponse)
+
+		for _, name := range []string{api.CharacterMythicRequest, api.CharacterPvPRequest} {
+			if !errors.Is(response.Errors[name], api.ErrNotFound) {
+				t.Errorf("Expected %s to be not found, got %v", name, response.Errors[name])
+			}
+		}
+		if failed := response.FailedRequests(); len(failed) > 0 {
+			t.Errorf("Expected no failed sub-requests, got %v", failed)
+		}
+
+		data, err := models.NewCharacterData(response, "eu")
+		if err != nil {
+			t.Fatalf("Failed to create character data: %v", err)
+		}
+		if len(data.MissingSections) > 0 {
+			t.Errorf("Expected no missing sections, got %v", data.MissingSections)
+		}
 	}
-	// Get Blizzard API credentials from environment variables
-	clientID := os.Getenv("CLIENT_ID")
-	clientSecret := os.Getenv("CLIENT_SECRET")
+}
 
-	if clientID == "" || clientSecret == "" {
-		t.Fatal("CLIENT_ID and CLIENT_SECRET environment variables must be set")
+// testGetCharacterPvPBracketFailed tests that a PvP bracket that fails to load is reported as missing
+func testGetCharacterPvPBracketFailed(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		if u.fake == nil {
+			t.Skip("Failed PvP brackets are only checked against the fake upstream")
+		}
+		u.fake.FailPath("/pvp-bracket/3v3", http.StatusInternalServerError)
+		t.Cleanup(func() { u.fake.FailPath("/pvp-bracket/3v3", 0) })
+
+		client := u.blizzardClient(u.clientID, u.clientSecret)
+		characterData, err := client.GetCharacterProfile(context.Background(), "eu", "darkspear", "tempests")
+		if err != nil {
+			t.Fatalf("Failed to get character profile: %v", err)
+		}
+		response := characterData.(*api.CharacterResponse)
+
+		// The other brackets are still shown
+		if response.PvP == nil || len(response.PvP.Brackets) != 2 {
+			t.Fatalf("Expected the 2 brackets that loaded, got %+v", response.PvP)
+		}
+		if failed := response.FailedRequests(); !slices.Contains(failed, api.CharacterPvPBrackets) {
+			t.Errorf("Expected %s in the failed sub-requests, got %v", api.CharacterPvPBrackets, failed)
+		}
+
+		data, err := models.NewCharacterData(response, "eu")
+		if err != nil {
+			t.Fatalf("Failed to create character data: %v", err)
+		}
+		if !slices.Contains(data.MissingSections, "PvP brackets") {
+			t.Errorf("Expected PvP brackets in the missing sections, got %v", data.MissingSections)
+		}
 	}
+}
 
-	// Create a new Blizzard API client
-	client := api.NewTokenClient(clientID, clientSecret)
+// testGetCharacterMythicSeason tests that only the best runs of the current Mythic+ season are shown
+func testGetCharacterMythicSeason(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		if u.fake == nil {
+			t.Skip("The current Mythic+ season is only checked against the fake upstream")
+		}
+		client := u.blizzardClient(u.clientID, u.clientSecret)
+
+		for _, tc := range []struct {
+			character string
+			seasonID  int
+		}{
+			{"tempests", 14},
+			// Stonewatch last ran keys in season 13, those runs are not this season's
+			{"stonewatch", 0},
+		} {
+			characterData, err := client.GetCharacterProfile(context.Background(), "eu", "darkspear", tc.character)
+			if err != nil {
+				t.Fatalf("Failed to get character profile of %s: %v", tc.character, err)
+			}
+			data, err := models.NewCharacterData(characterData, "eu")
+			if err != nil {
+				t.Fatalf("Failed to create character data of %s: %v", tc.character, err)
+			}
 
-	// Get an access token
-	token, err := client.GetAccessToken()
-	if err != nil {
-		t.Fatalf("Failed to get access token: %v", err)
+			if data.MythicPlus == nil || data.MythicPlus.SeasonID != tc.seasonID {
+				t.Errorf("Expected %s to show season %d, got %+v", tc.character, tc.seasonID, data.MythicPlus)
+			}
+			if tc.seasonID == 0 && len(data.MythicPlus.BestRuns) > 0 {
+				t.Errorf("Expected no best runs for %s, got %d", tc.character, len(data.MythicPlus.BestRuns))
+			}
+			if len(data.MissingSections) > 0 {
+				t.Errorf("Expected no missing sections for %s, got %v", tc.character, data.MissingSections)
+			}
+		}
+
+		server := setupPageServer(t, u, u.clientSecret)
+		if _, body := getPage(t, server, "/?region=eu&realm=darkspear&character=tempests"); !strings.Contains(body, "Season 14") {
+			t.Error("Expected the character page to label the season of the best runs")
+		}
+		if _, body := getPage(t, server, "/?region=eu&realm=darkspear&character=stonewatch"); !strings.Contains(body, "No Mythic+ runs this season") {
+			t.Error("Expected the character page to show no runs this season")
+		}
 	}
+}
+
+// testCharacterPageRaids tests that the raid progression is labelled with the expansion it is from
+func testCharacterPageRaids(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		if u.fake == nil {
+			t.Skip("The raided expansion is only checked against the fake upstream")
+		}
+		server := setupPageServer(t, u, u.clientSecret)
+
+		// Tempests raided in Dragonflight and The War Within, only the latter is shown
+		_, body := getPage(t, server, "/?region=eu&realm=darkspear&character=tempests")
+		if !strings.Contains(body, "Last raided in The War Within") {
+			t.Error("Expected the character page to name the expansion of the raid progression")
+		}
+	}
+}
+
+// TestGetTokenPrice tests the GetTokenPrice function
+func TestGetTokenPrice(t *testing.T) {
+	u := setupUpstream(t)
+
+	if u.clientID == "" || u.clientSecret == "" {
+		t.Fatal("CLIENT_ID and CLIENT_SECRET environment variables must be set")
+	}
+
+	// Create a new Token API client
+	client := api.NewTokenClient(u.config.BlizzardAPIURL, u.tokenProvider(u.clientID, u.clientSecret), nil)
 
 	region := "eu"
 
 	// Get token price
-	price, err := client.GetTokenPrice(token, region)
+	price, err := client.GetTokenPrice(context.Background(), region)
 	if err != nil {
 		t.Fatalf("Failed to get token price: %v", err)
 	}
@@ -130,12 +344,14 @@ func TestGetTokenPrice(t *testing.T) {
 
 // TestBlizzardAPIErrorHandling tests error handling in the Blizzard API client
 func TestBlizzardAPIErrorHandling(t *testing.T) {
+	u := setupUpstream(t)
+
 	t.Run("InvalidCredentials", func(t *testing.T) {
 		// Create a client with invalid credentials
-		client := api.NewBlizzardClient("invalid_id", "invalid_secret")
+		client := u.blizzardClient("invalid_id", "invalid_secret")
 
 		// Attempt to get an access token
-		_, err := client.GetAccessToken()
+		_, err := client.GetAccessToken(context.Background())
 		if err == nil {
 			t.Fatal("Expected error with invalid credentials, but got none")
 		}
@@ -143,25 +359,15 @@ func TestBlizzardAPIErrorHandling(t *testing.T) {
 	})
 
 	t.Run("InvalidCharacter", func(t *testing.T) {
-		// Get valid credentials
-		clientID := os.Getenv("CLIENT_ID")
-		clientSecret := os.Getenv("CLIENT_SECRET")
-
-		if clientID == "" || clientSecret == "" {
+		if u.clientID == "" || u.clientSecret == "" {
 			t.Skip("Skipping test due to missing credentials")
 		}
 
 		// Create a client with valid credentials
-		client := api.NewBlizzardClient(clientID, clientSecret)
-
-		// Get a valid access token
-		token, err := client.GetAccessToken()
-		if err != nil {
-			t.Fatalf("Failed to get access token: %v", err)
-		}
+		client := u.blizzardClient(u.clientID, u.clientSecret)
 
 		// Attempt to get a non-existent character
-		_, err = client.GetCharacterProfile(token, "eu", "silvermoon", "nonexistentcharacter123456789")
+		_, err := client.GetCharacterProfile(context.Background(), "eu", "silvermoon", "nonexistentcharacter123456789")
 		if err == nil {
 			t.Fatal("Expected error with non-existent character, but got none")
 		}
@@ -170,28 +376,22 @@ func TestBlizzardAPIErrorHandling(t *testing.T) {
 
 	t.Run("MissingParameters", func(t *testing.T) {
 		// Create a client
-		client := api.NewBlizzardClient("id", "secret")
-
-		// Test with empty access token
-		_, err := client.GetCharacterProfile("", "eu", "silvermoon", "thrall")
-		if err == nil {
-			t.Fatal("Expected error with empty access token, but got none")
-		}
+		client := u.blizzardClient("id", "secret")
 
 		// Test with empty region
-		_, err = client.GetCharacterProfile("token", "", "silvermoon", "thrall")
+		_, err := client.GetCharacterProfile(context.Background(), "", "silvermoon", "thrall")
 		if err == nil {
 			t.Fatal("Expected error with empty region, but got none")
 		}
 
 		// Test with empty realm
-		_, err = client.GetCharacterProfile("token", "eu", "", "thrall")
+		_, err = client.GetCharacterProfile(context.Background(), "eu", "", "thrall")
 		if err == nil {
 			t.Fatal("Expected error with empty realm, but got none")
 		}
 
 		// Test with empty character
-		_, err = client.GetCharacterProfile("token", "eu", "silvermoon", "")
+		_, err = client.GetCharacterProfile(context.Background(), "eu", "silvermoon", "")
 		if err == nil {
 			t.Fatal("Expected error with empty character, but got none")
 		}
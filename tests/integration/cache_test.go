@@ -0,0 +1,230 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wowarmory/internal/api"
+	"wowarmory/internal/cache"
+	"wowarmory/internal/config"
+	"wowarmory/internal/fakeupstream"
+	"wowarmory/internal/interfaces"
+)
+
+// memoryStore is an in-memory CacheStore so the cache tests do not need Redis
+type memoryStore struct {
+	mu     sync.Mutex
+	values map[string][]byte
+	ttls   map[string]time.Duration
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{values: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (s *memoryStore) GetCache(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	if !ok {
+		return nil, interfaces.ErrCacheMiss
+	}
+	return value, nil
+}
+
+func (s *memoryStore) SetCache(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	s.ttls[key] = ttl
+	return nil
+}
+
+func (s *memoryStore) DeleteCache(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+	delete(s.ttls, key)
+	return nil
+}
+
+// countingBlizzard counts the character lookups that reach the wrapped client
+type countingBlizzard struct {
+	interfaces.BlizzardAPI
+	lookups atomic.Int64
+}
+
+func (c *countingBlizzard) GetCharacterProfile(ctx context.Context, region, realm, character string) (interface{}, error) {
+	c.lookups.Add(1)
+	return c.BlizzardAPI.GetCharacterProfile(ctx, region, realm, character)
+}
+
+// countingWarcraftlogs counts the guild lookups that reach the wrapped client
+type countingWarcraftlogs struct {
+	interfaces.WarcraftLogsAPI
+	lookups atomic.Int64
+}
+
+func (c *countingWarcraftlogs) GetGuild(ctx context.Context, name, serverSlug, serverRegion string) (interface{}, error) {
+	c.lookups.Add(1)
+	return c.WarcraftLogsAPI.GetGuild(ctx, name, serverSlug, serverRegion)
+}
+
+// TestResponseCache tests the caching decorators against the upstream APIs
+func TestResponseCache(t *testing.T) {
+	u := setupUpstream(t)
+
+	cfg := config.CacheConfig{
+		CharacterTTL:           time.Minute,
+		CharacterRevalidateTTL: time.Hour,
+		GuildTTL:               time.Minute,
+	}
+
+	t.Run("CharacterServedFromCache", testCharacterServedFromCache(u, cfg))
+	t.Run("CharacterRevalidated", testCharacterRevalidated(u, "tempests"))
+	t.Run("CharacterWithoutHistoryRevalidated", func(t *testing.T) {
+		if u.fake == nil {
+			t.Skip("Characters without Mythic+ and PvP history are only checked against the fake upstream")
+		}
+		// Tidecaller has no Mythic+ or PvP data, which does not make it a partial character
+		testCharacterRevalidated(u, "tidecaller")(t)
+	})
+	t.Run("InvalidateCharacter", testInvalidateCharacter(u, cfg))
+	t.Run("GuildServedFromCache", testGuildServedFromCache(u, cfg))
+}
+
+// testCharacterServedFromCache tests that a cached character does not reach the API again
+func testCharacterServedFromCache(u *upstream, cfg config.CacheConfig) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := &countingBlizzard{BlizzardAPI: u.blizzardClient(u.clientID, u.clientSecret)}
+		cached := cache.NewBlizzardCache(client, newMemoryStore(), cfg)
+
+		for i := 0; i < 2; i++ {
+			response, err := cached.GetCharacterProfile(context.Background(), "eu", "darkspear", "tempests")
+			if err != nil {
+				t.Fatalf("Failed to get character profile: %v", err)
+			}
+
+			character, ok := response.(*api.CharacterResponse)
+			if !ok || character.Profile == nil {
+				t.Fatalf("Expected a character response, got %T", response)
+			}
+			if character.Profile.Name == "" {
+				t.Error("Cached character has no name")
+			}
+			if character.PvP != nil && len(character.PvP.Brackets) > 0 && character.PvP.Brackets[0].Slug == "" {
+				t.Error("Cached PvP bracket lost its slug")
+			}
+		}
+
+		if lookups := client.lookups.Load(); lookups != 1 {
+			t.Errorf("Expected 1 upstream lookup, got %d", lookups)
+		}
+	}
+}
+
+// testCharacterRevalidated tests that an expired character is served again when it did not change
+func testCharacterRevalidated(u *upstream, character string) func(t *testing.T) {
+	return func(t *testing.T) {
+		// Expire characters immediately so every lookup has to revalidate
+		cfg := config.CacheConfig{
+			CharacterTTL:           time.Nanosecond,
+			CharacterRevalidateTTL: time.Hour,
+		}
+		client := &countingBlizzard{BlizzardAPI: u.blizzardClient(u.clientID, u.clientSecret)}
+		cached := cache.NewBlizzardCache(client, newMemoryStore(), cfg)
+
+		response, err := cached.GetCharacterProfile(context.Background(), "eu", "darkspear", character)
+		if err != nil {
+			t.Fatalf("Failed to get character profile: %v", err)
+		}
+		if response.(*api.CharacterResponse).LastModified == "" {
+			t.Skip("Upstream did not send Last-Modified, nothing to revalidate")
+		}
+
+		time.Sleep(time.Millisecond)
+		if _, err := cached.GetCharacterProfile(context.Background(), "eu", "darkspear", character); err != nil {
+			t.Fatalf("Failed to get revalidated character profile: %v", err)
+		}
+
+		if lookups := client.lookups.Load(); lookups != 1 {
+			t.Errorf("Expected the unchanged character to be served from cache, got %d upstream lookups", lookups)
+		}
+	}
+}
+
+// testInvalidateCharacter tests that invalidating a character fetches it again
+func testInvalidateCharacter(u *upstream, cfg config.CacheConfig) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := &countingBlizzard{BlizzardAPI: u.blizzardClient(u.clientID, u.clientSecret)}
+		cached := cache.NewBlizzardCache(client, newMemoryStore(), cfg)
+
+		if _, err := cached.GetCharacterProfile(context.Background(), "eu", "darkspear", "tempests"); err != nil {
+			t.Fatalf("Failed to get character profile: %v", err)
+		}
+		if err := cached.InvalidateCharacter(context.Background(), "eu", "darkspear", "tempests"); err != nil {
+			t.Fatalf("Failed to invalidate character: %v", err)
+		}
+		if _, err := cached.GetCharacterProfile(context.Background(), "eu", "darkspear", "tempests"); err != nil {
+			t.Fatalf("Failed to get character profile: %v", err)
+		}
+
+		if lookups := client.lookups.Load(); lookups != 2 {
+			t.Errorf("Expected 2 upstream lookups, got %d", lookups)
+		}
+	}
+}
+
+// testGuildServedFromCache tests that a cached guild does not reach the API again
+func testGuildServedFromCache(u *upstream, cfg config.CacheConfig) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := &countingWarcraftlogs{WarcraftLogsAPI: u.warcraftlogsClient(u.warcraftlogsToken)}
+		cached := cache.NewWarcraftlogsCache(client, newMemoryStore(), cfg)
+
+		for i := 0; i < 2; i++ {
+			response, err := cached.GetGuild(context.Background(), "Divine Intervention", "darkspear", "eu")
+			if err != nil {
+				t.Fatalf("Failed to get guild: %v", err)
+			}
+
+			guild, ok := response.(*api.GuildResponse)
+			if !ok || guild.GuildData.Guild.Name == "" {
+				t.Fatalf("Expected a guild response, got %#v", response)
+			}
+		}
+
+		if lookups := client.lookups.Load(); lookups != 1 {
+			t.Errorf("Expected 1 upstream lookup, got %d", lookups)
+		}
+	}
+}
+
+// TestCharacterModifiedSince tests the conditional request used to revalidate characters
+func TestCharacterModifiedSince(t *testing.T) {
+	u := setupUpstream(t)
+	if u.fake == nil {
+		t.Skip("Last-Modified times of live characters are unknown")
+	}
+
+	client := u.blizzardClient(u.clientID, u.clientSecret)
+	unchanged := fakeupstream.FixturesModified.Format(http.TimeFormat)
+
+	modified, err := client.CharacterModifiedSince(context.Background(), "eu", "darkspear", "tempests", unchanged)
+	if err != nil {
+		t.Fatalf("Failed to revalidate character: %v", err)
+	}
+	if modified {
+		t.Error("Expected the character to be unchanged")
+	}
+
+	modified, err = client.CharacterModifiedSince(context.Background(), "eu", "darkspear", "tempests", "Mon, 01 Jan 2024 00:00:00 GMT")
+	if err != nil {
+		t.Fatalf("Failed to revalidate character: %v", err)
+	}
+	if !modified {
+		t.Error("Expected the character to be modified")
+	}
+}
@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+	"wowarmory/internal/api"
+	"wowarmory/internal/config"
+	"wowarmory/internal/handlers"
+)
+
+// TestLookupCancellation tests that lookups stop when their context ends instead of retrying a failing upstream
+func TestLookupCancellation(t *testing.T) {
+	t.Run("Deadline", testLookupDeadline)
+	t.Run("Cancelled", testLookupCancelled)
+	t.Run("HandlerTimeout", testHandlerTimeout)
+}
+
+// testLookupDeadline tests that a character lookup fails with ErrTimeout once its deadline passes
+func testLookupDeadline(t *testing.T) {
+	u := setupUpstream(t)
+	if u.fake == nil {
+		t.Skip("Deadlines are only checked against the fake upstream")
+	}
+	client := u.blizzardClient(u.clientID, u.clientSecret)
+
+	// Every resource keeps failing so the lookup would retry with backoff for seconds
+	u.fake.FailNext(100, http.StatusServiceUnavailable, "")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetCharacterProfile(ctx, "eu", "darkspear", "tempests")
+	if !errors.Is(err, api.ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the lookup to stop at its deadline, took %s", elapsed)
+	}
+}
+
+// testLookupCancelled tests that a cancelled context stops a token price lookup before it is sent
+func testLookupCancelled(t *testing.T) {
+	u := setupUpstream(t)
+	client := api.NewTokenClient(u.config.BlizzardAPIURL, u.tokenProvider(u.clientID, u.clientSecret), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := client.GetTokenPrice(ctx, "eu"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// testHandlerTimeout tests that the configured lookup timeout of a handler is answered with 504
+func testHandlerTimeout(t *testing.T) {
+	u := setupUpstream(t)
+	if u.fake == nil {
+		t.Skip("Handler timeouts are only checked against the fake upstream")
+	}
+
+	cfg := &config.Config{
+		AssetsDir: "../../assets",
+		Lookup:    config.LookupConfig{CharacterTimeout: 100 * time.Millisecond},
+	}
+	server := startAPIServer(t, cfg, u, u.warcraftlogsClient(u.warcraftlogsToken))
+
+	u.fake.FailNext(100, http.StatusServiceUnavailable, "")
+	var body map[string]handlers.APIError
+	status := getJSON(t, server, "/api/v1/characters/eu/darkspear/tempests", &body)
+	if status != http.StatusGatewayTimeout {
+		t.Errorf("Expected status %d, got %d", http.StatusGatewayTimeout, status)
+	}
+	if body["error"].Code != handlers.ErrorCodeUpstreamTimeout {
+		t.Errorf("Expected error code %q, got %q", handlers.ErrorCodeUpstreamTimeout, body["error"].Code)
+	}
+}
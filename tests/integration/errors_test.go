@@ -0,0 +1,178 @@
+package integration
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"wowarmory/internal/api"
+	"wowarmory/internal/config"
+	"wowarmory/internal/fakeupstream"
+	"wowarmory/internal/handlers"
+	"wowarmory/internal/interfaces"
+	"wowarmory/internal/router"
+	"wowarmory/internal/templates"
+)
+
+// setupPageServer starts the application router with the HTML lookup handlers against the upstream
+func setupPageServer(t *testing.T, u *upstream, clientSecret string) *httptest.Server {
+	t.Helper()
+
+	cfg := &config.Config{AssetsDir: "../../assets", TemplatesDir: "../../internal/templates"}
+	templateMgr, err := templates.NewManager(cfg.TemplatesDir)
+	if err != nil {
+		t.Fatalf("Failed to load templates: %v", err)
+	}
+
+	base := handlers.NewBaseHandler(cfg, &memorySearchStore{}, templateMgr)
+	r := router.New(cfg)
+	tokens := u.tokenProvider(u.clientID, clientSecret)
+	blizzardClient := api.NewBlizzardClient(u.config.BlizzardAPIURL, tokens, nil)
+	warcraftlogsClient := u.warcraftlogsClient(u.warcraftlogsToken)
+	r.SetupHandlers([]interfaces.Handler{
+		handlers.NewCharacterHandler(base, blizzardClient, warcraftlogsClient),
+		handlers.NewGuildHandler(base, blizzardClient, warcraftlogsClient),
+		handlers.NewReportHandler(base, warcraftlogsClient),
+		handlers.NewTokenHandler(base, api.NewTokenClient(u.config.BlizzardAPIURL, tokens, nil)),
+	})
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// getPage requests a page from the server and returns its status code and body
+func getPage(t *testing.T, server *httptest.Server, path string) (int, string) {
+	t.Helper()
+
+	resp, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatalf("Failed to request %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+// TestLookupErrors tests that failed lookups are rendered with a status code matching the failure
+func TestLookupErrors(t *testing.T) {
+	if setupUpstream(t).fake == nil {
+		t.Skip("Lookup errors are only checked against the fake upstream")
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		secret     string
+		fail       func(fake *fakeupstream.Server)
+		status     int
+		retryAfter string
+	}{
+		{
+			name:   "CharacterNotFound",
+			path:   "/?region=eu&realm=darkspear&character=nonexistentcharacter123",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "CharacterPartialNotFound",
+			path:   "/character?region=eu&realm=darkspear&character=nonexistentcharacter123",
+			status: http.StatusNotFound,
+		},
+		{
+			name:       "CharacterPartialRateLimited",
+			path:       "/character?region=eu&realm=darkspear&character=tempests",
+			fail:       func(fake *fakeupstream.Server) { fake.FailNext(100, http.StatusTooManyRequests, "60") },
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "60",
+		},
+		{
+			name:   "GuildPartialNotFound",
+			path:   "/guild?region=eu&realm=darkspear&guild=nonexistentguild123456789",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "GuildNotFound",
+			path:   "/guild-lookup?region=eu&realm=darkspear&guild=nonexistentguild123456789",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "InvalidCredentials",
+			path:   "/?region=eu&realm=darkspear&character=tempests",
+			secret: "invalid_client_secret",
+			status: http.StatusBadGateway,
+		},
+		{
+			name:   "Outage",
+			path:   "/?region=eu&realm=darkspear&character=tempests",
+			fail:   func(fake *fakeupstream.Server) { fake.FailNext(100, http.StatusServiceUnavailable, "0") },
+			status: http.StatusBadGateway,
+		},
+		{
+			name:       "RateLimited",
+			path:       "/?region=eu&realm=darkspear&character=tempests",
+			fail:       func(fake *fakeupstream.Server) { fake.FailNext(100, http.StatusTooManyRequests, "60") },
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "60",
+		},
+		{
+			name:       "TokenRateLimited",
+			path:       "/token",
+			fail:       func(fake *fakeupstream.Server) { fake.FailNext(100, http.StatusTooManyRequests, "60") },
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "60",
+		},
+		{
+			name:   "TokenOutage",
+			path:   "/token",
+			fail:   func(fake *fakeupstream.Server) { fake.FailNext(100, http.StatusServiceUnavailable, "0") },
+			status: http.StatusBadGateway,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Every case gets its own upstream so injected failures do not leak into the next
+			u := setupUpstream(t)
+			secret := u.clientSecret
+			if tc.secret != "" {
+				secret = tc.secret
+			}
+			server := setupPageServer(t, u, secret)
+			if tc.fail != nil {
+				tc.fail(u.fake)
+			}
+
+			resp, err := http.Get(server.URL + tc.path)
+			if err != nil {
+				t.Fatalf("Failed to request %s: %v", tc.path, err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.status {
+				t.Errorf("Expected status %d, got %d", tc.status, resp.StatusCode)
+			}
+			if retryAfter := resp.Header.Get("Retry-After"); retryAfter != tc.retryAfter {
+				t.Errorf("Expected Retry-After %q, got %q", tc.retryAfter, retryAfter)
+			}
+		})
+	}
+}
+
+// TestErrorKinds tests that the typed upstream errors match their kinds
+func TestErrorKinds(t *testing.T) {
+	budgetErr := error(&api.BudgetExhaustedError{Region: "eu", RetryAfter: time.Second})
+	if !errors.Is(budgetErr, api.ErrRateLimited) || !errors.Is(budgetErr, api.ErrBudgetExhausted) {
+		t.Error("Expected an exhausted budget to be rate limited")
+	}
+
+	apiErr := error(&api.Error{Kind: api.ErrTimeout, Client: "BlizzardAPI", Err: errors.New("i/o timeout")})
+	if !errors.Is(apiErr, api.ErrTimeout) || errors.Is(apiErr, api.ErrUpstream) {
+		t.Errorf("Expected only ErrTimeout to match %v", apiErr)
+	}
+}
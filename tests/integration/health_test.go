@@ -0,0 +1,105 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wowarmory/internal/api"
+	"wowarmory/internal/config"
+	"wowarmory/internal/handlers"
+	"wowarmory/internal/interfaces"
+	"wowarmory/internal/router"
+)
+
+// unavailableSearchStore is a SearchStore whose backend cannot be reached
+type unavailableSearchStore struct {
+	memorySearchStore
+}
+
+func (s *unavailableSearchStore) Ping(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+// setupHealthServer starts the application router with the health handler against the upstream
+func setupHealthServer(t *testing.T, u *upstream, store interfaces.SearchStore) *httptest.Server {
+	t.Helper()
+
+	cfg := &config.Config{AssetsDir: "../../assets"}
+	base := handlers.NewBaseHandler(cfg, store, nil)
+	healthHandler := handlers.NewHealthHandler(base,
+		api.NewBlizzardClient(u.config.BlizzardAPIURL, u.tokenProvider(u.clientID, u.clientSecret), nil),
+		u.warcraftlogsClient(u.warcraftlogsToken),
+	)
+
+	r := router.New(cfg)
+	r.SetupHandlers([]interfaces.Handler{healthHandler})
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestHealthEndpoints tests the liveness and readiness endpoints
+func TestHealthEndpoints(t *testing.T) {
+	u := setupUpstream(t)
+	if u.clientID == "" || u.clientSecret == "" || u.warcraftlogsToken == "" {
+		t.Skip("Skipping test due to missing credentials")
+	}
+
+	t.Run("Liveness", func(t *testing.T) {
+		server := setupHealthServer(t, u, &unavailableSearchStore{})
+
+		var body handlers.HealthResponse
+		if status := getJSON(t, server, "/healthz", &body); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if body.Status != handlers.HealthStatusOK {
+			t.Errorf("Expected status %q, got %q", handlers.HealthStatusOK, body.Status)
+		}
+	})
+
+	t.Run("Ready", func(t *testing.T) {
+		server := setupHealthServer(t, u, &memorySearchStore{})
+
+		var body handlers.HealthResponse
+		if status := getJSON(t, server, "/readyz", &body); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %+v", status, body)
+		}
+		for _, name := range []string{"redis", "blizzard", "warcraftlogs"} {
+			if check, ok := body.Checks[name]; !ok || check.Status != handlers.HealthStatusOK {
+				t.Errorf("Expected %s check to be ok, got %+v", name, check)
+			}
+		}
+	})
+
+	t.Run("RedisUnavailable", func(t *testing.T) {
+		server := setupHealthServer(t, u, &unavailableSearchStore{})
+
+		var body handlers.HealthResponse
+		if status := getJSON(t, server, "/readyz", &body); status != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status 503, got %d", status)
+		}
+		if check := body.Checks["redis"]; check.Status != handlers.HealthStatusUnavailable || check.Error == "" {
+			t.Errorf("Expected redis check to fail with an error, got %+v", check)
+		}
+		if check := body.Checks["blizzard"]; check.Status != handlers.HealthStatusOK {
+			t.Errorf("Expected blizzard check to be ok, got %+v", check)
+		}
+	})
+
+	t.Run("InvalidCredentials", func(t *testing.T) {
+		invalid := *u
+		invalid.clientSecret = "invalid_client_secret"
+		server := setupHealthServer(t, &invalid, &memorySearchStore{})
+
+		var body handlers.HealthResponse
+		if status := getJSON(t, server, "/readyz", &body); status != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status 503, got %d", status)
+		}
+		if check := body.Checks["blizzard"]; check.Status != handlers.HealthStatusUnavailable {
+			t.Errorf("Expected blizzard check to fail, got %+v", check)
+		}
+	})
+}
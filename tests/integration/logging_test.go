@@ -0,0 +1,109 @@
+package integration
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wowarmory/internal/logging"
+	"wowarmory/internal/middleware"
+)
+
+// TestLoggingMiddleware tests the request logging and request ID propagation
+func TestLoggingMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /characters/{name}", func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Info("handler log")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	})
+	server := httptest.NewServer(middleware.LoggingMiddleware(logger, mux))
+	defer server.Close()
+
+	t.Run("GeneratesRequestID", func(t *testing.T) {
+		logs.Reset()
+		resp, err := http.Get(server.URL + "/characters/tempests")
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+
+		requestID := resp.Header.Get(middleware.RequestIDHeader)
+		if requestID == "" {
+			t.Fatal("Expected a generated request ID")
+		}
+
+		entries := decodeLogs(t, &logs)
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 log entries, got %d", len(entries))
+		}
+		for _, entry := range entries {
+			if entry["request_id"] != requestID {
+				t.Errorf("Expected request ID %q in %v", requestID, entry)
+			}
+		}
+
+		request := entries[1]
+		if request["route"] != "GET /characters/{name}" {
+			t.Errorf("Expected the route pattern, got %v", request["route"])
+		}
+		if request["status"] != float64(http.StatusTeapot) {
+			t.Errorf("Expected status %d, got %v", http.StatusTeapot, request["status"])
+		}
+		if request["bytes"] != float64(len("short and stout")) {
+			t.Errorf("Expected %d bytes, got %v", len("short and stout"), request["bytes"])
+		}
+	})
+
+	t.Run("PropagatesRequestID", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/characters/tempests", nil)
+		req.Header.Set(middleware.RequestIDHeader, "bot-request-1")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+
+		if requestID := resp.Header.Get(middleware.RequestIDHeader); requestID != "bot-request-1" {
+			t.Errorf("Expected the client request ID, got %q", requestID)
+		}
+	})
+
+	t.Run("RejectsInvalidRequestID", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/characters/tempests", nil)
+		req.Header.Set(middleware.RequestIDHeader, "bad id\twith spaces")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+
+		if requestID := resp.Header.Get(middleware.RequestIDHeader); requestID == "bad id\twith spaces" || requestID == "" {
+			t.Errorf("Expected a generated request ID, got %q", requestID)
+		}
+	})
+}
+
+// decodeLogs decodes JSON log lines
+func decodeLogs(t *testing.T, logs *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(logs)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to decode log line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wowarmory/internal/api"
+	"wowarmory/internal/config"
+	"wowarmory/internal/handlers"
+	"wowarmory/internal/interfaces"
+	"wowarmory/internal/metrics"
+	"wowarmory/internal/middleware"
+	"wowarmory/internal/router"
+)
+
+// TestMetricsEndpoint tests that HTTP and upstream calls show up in the metrics
+func TestMetricsEndpoint(t *testing.T) {
+	u := setupUpstream(t)
+	if u.fake == nil {
+		t.Skip("Metrics are only checked against the fake upstream")
+	}
+
+	cfg := &config.Config{AssetsDir: "../../assets"}
+	tokens := u.tokenProvider(u.clientID, u.clientSecret)
+	base := handlers.NewBaseHandler(cfg, &memorySearchStore{}, nil)
+
+	r := router.New(cfg)
+	r.SetupHandlers([]interfaces.Handler{
+		handlers.NewAPIHandler(base,
+			api.NewBlizzardClient(u.config.BlizzardAPIURL, tokens, nil),
+			u.warcraftlogsClient(u.warcraftlogsToken),
+			api.NewTokenClient(u.config.BlizzardAPIURL, tokens, nil),
+		),
+		handlers.NewMetricsHandler(base),
+	})
+	server := httptest.NewServer(middleware.MetricsMiddleware(r))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/characters/eu/darkspear/tempests")
+	if err != nil {
+		t.Fatalf("Failed to get character: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+
+	expected := []string{
+		`wowarmory_http_requests_total{method="GET",route="GET /api/v1/characters/{region}/{realm}/{name}",status="200"}`,
+		`wowarmory_upstream_requests_total{client="BlizzardAPI",endpoint="character/equipment",status="200"}`,
+		`wowarmory_upstream_requests_total{client="BlizzardAPI",endpoint="character/pvp-bracket",status="200"}`,
+		`wowarmory_upstream_request_duration_seconds_count{client="BlizzardAPI",endpoint="character"}`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("Expected metrics to contain %s", line)
+		}
+	}
+	if strings.Contains(string(body), "tempests") {
+		t.Error("Character names must not be used as metric labels")
+	}
+}
+
+// TestMetricsEndpointLabel tests the normalization of upstream paths into endpoint labels
+func TestMetricsEndpointLabel(t *testing.T) {
+	tests := map[string]string{
+		"/profile/wow/character/darkspear/tempests":                                   "character",
+		"/profile/wow/character/darkspear/tempests/equipment":                         "character/equipment",
+		"/eu/profile/wow/character/darkspear/tempests/encounters/raids":               "character/encounters/raids",
+		"/profile/wow/character/darkspear/tempests/mythic-keystone-profile/season/14": "character/mythic-keystone-profile/season",
+		"/profile/wow/character/darkspear/tempests/pvp-bracket/shuffle-mage-frost":    "character/pvp-bracket",
+		"/data/wow/token/index":                                "data/token/index",
+		"/data/wow/guild/darkspear/divine-intervention/roster": "guild/roster",
+		"/oauth/token":                                         "oauth/token",
+		"/api/v2/client":                                       "graphql",
+		"/warcraftlogs/api/v2/client":                          "graphql",
+	}
+
+	for path, expected := range tests {
+		if endpoint := metrics.Endpoint(path); endpoint != expected {
+			t.Errorf("Endpoint(%q) = %q, expected %q", path, endpoint, expected)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"wowarmory/internal/api"
+	"wowarmory/internal/cache"
+	"wowarmory/internal/config"
+	"wowarmory/internal/metrics"
+)
+
+// TestWarcraftlogsPoints tests the tracking of the Warcraftlogs points budget and the queries held back below the floor
+func TestWarcraftlogsPoints(t *testing.T) {
+	u := setupUpstream(t)
+	if u.fake == nil {
+		t.Skip("The points budget is only checked against the fake upstream")
+	}
+
+	t.Run("Tracked", testPointsTracked(u))
+	t.Run("AttendanceDegraded", testPointsAttendanceDegraded(u))
+	t.Run("ProgressionDegraded", testPointsProgressionDegraded(u))
+	t.Run("PartialCachedBriefly", testPointsPartialCachedBriefly(u))
+	t.Run("PartialGuildPage", testPointsPartialGuildPage(u))
+	t.Run("ReportTableRefused", testPointsReportTableRefused(u))
+	t.Run("NoFloor", testPointsNoFloor(u))
+	t.Run("Readiness", testPointsReadiness(u))
+	t.Run("Metrics", testPointsMetrics(u))
+}
+
+// pointsStatus returns the points budget of a client
+func pointsStatus(t *testing.T, client *api.WarcraftlogsClient) api.PointsStatus {
+	t.Helper()
+
+	status, ok := client.PointsStatus().(api.PointsStatus)
+	if !ok {
+		t.Fatalf("Failed to cast points status to PointsStatus: %T", client.PointsStatus())
+	}
+	return status
+}
+
+// testPointsTracked tests that the budget reported with every query is kept
+func testPointsTracked(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Cleanup(func() { u.fake.SetWarcraftlogsPointsSpent(0) })
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
+
+		if status := pointsStatus(t, client); status.Known {
+			t.Errorf("Expected an unknown budget before the first query, got %+v", status)
+		}
+
+		u.fake.SetWarcraftlogsPointsSpent(1200)
+		if _, err := client.GetReport(context.Background(), "a1B2c3D4e5F6g7H8"); err != nil {
+			t.Fatalf("Failed to get report: %v", err)
+		}
+
+		status := pointsStatus(t, client)
+		if !status.Known || status.LimitPerHour != 3600 || status.Spent != 1200 || status.Remaining != 2400 || status.Low {
+			t.Errorf("Expected 2400 of 3600 points left, got %+v", status)
+		}
+		if status.Floor != config.DefaultWarcraftlogsPointsFloor || status.ResetAt.IsZero() {
+			t.Errorf("Expected the default floor and a reset time, got %+v", status)
+		}
+	}
+}
+
+// testPointsAttendanceDegraded tests that only the first attendance page is used below the floor
+func testPointsAttendanceDegraded(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Cleanup(func() { u.fake.SetWarcraftlogsPointsSpent(0) })
+		u.fake.SetWarcraftlogsPointsSpent(3500)
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
+
+		// The guild query itself reports the budget, the pages after it are held back
+		response := getGuildResponse(t, client)
+		if raids := len(response.GuildData.Guild.Attendance.Data); raids != 3 {
+			t.Errorf("Expected the 3 raids of the first page, got %d", raids)
+		}
+		if !response.Partial {
+			t.Error("Expected the attendance to be partial")
+		}
+		if status := pointsStatus(t, client); !status.Low || status.Remaining != 100 {
+			t.Errorf("Expected a low budget of 100 points, got %+v", status)
+		}
+	}
+}
+
+// testPointsProgressionDegraded tests that only the first progression reports page is used below the floor
+func testPointsProgressionDegraded(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Cleanup(func() { u.fake.SetWarcraftlogsPointsSpent(0) })
+		u.fake.SetWarcraftlogsPointsSpent(3500)
+
+		response := getProgression(t, u, 0, 0)
+		if !response.Partial || !response.ReportData.Reports.HasMorePages {
+			t.Errorf("Expected partial progression with more pages left, got partial %v", response.Partial)
+		}
+	}
+}
+
+// testPointsPartialCachedBriefly tests that guilds and progression cut short are not cached for the guild TTL
+func testPointsPartialCachedBriefly(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Cleanup(func() { u.fake.SetWarcraftlogsPointsSpent(0) })
+		u.fake.SetWarcraftlogsPointsSpent(3500)
+		store := newMemoryStore()
+		cached := cache.NewWarcraftlogsCache(u.warcraftlogsClient(u.warcraftlogsToken), store, config.CacheConfig{GuildTTL: time.Hour})
+
+		if _, err := cached.GetGuild(context.Background(), "Divine Intervention", "darkspear", "eu"); err != nil {
+			t.Fatalf("Failed to get guild: %v", err)
+		}
+		if _, err := cached.GetGuildProgression(context.Background(), "Divine Intervention", "darkspear", "eu", 0, 0); err != nil {
+			t.Fatalf("Failed to get guild progression: %v", err)
+		}
+
+		if len(store.ttls) != 2 {
+			t.Fatalf("Expected the guild and progression to be cached, got %v", store.ttls)
+		}
+		for key, ttl := range store.ttls {
+			if ttl >= time.Hour {
+				t.Errorf("Expected %s to be cached briefly, got %s", key, ttl)
+			}
+		}
+	}
+}
+
+// testPointsPartialGuildPage tests that the guild page tells attendance and progression are incomplete
+func testPointsPartialGuildPage(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Cleanup(func() { u.fake.SetWarcraftlogsPointsSpent(0) })
+		u.fake.SetWarcraftlogsPointsSpent(3500)
+		server := setupPageServer(t, u, u.clientSecret)
+
+		status, body := getPage(t, server, "/guild?region=eu&realm=darkspear&guild=divine+intervention")
+		if status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		for _, want := range []string{"attendance does not cover the whole window", "first kills and pulls may be incomplete"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Expected the guild page to contain %q", want)
+			}
+		}
+	}
+}
+
+// testPointsReportTableRefused tests that report tables are refused below the floor while reports still load
+func testPointsReportTableRefused(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Cleanup(func() { u.fake.SetWarcraftlogsPointsSpent(0) })
+		u.fake.SetWarcraftlogsPointsSpent(3500)
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
+
+		if _, err := client.GetReport(context.Background(), "a1B2c3D4e5F6g7H8"); err != nil {
+			t.Fatalf("Failed to get report: %v", err)
+		}
+
+		_, err := client.GetReportTable(context.Background(), "a1B2c3D4e5F6g7H8", 9, api.TableDamageDone)
+		if !errors.Is(err, api.ErrRateLimited) {
+			t.Fatalf("Expected ErrRateLimited, got %v", err)
+		}
+		var apiErr *api.Error
+		if !errors.As(err, &apiErr) || apiErr.RetryAfter <= 0 {
+			t.Errorf("Expected a Retry-After until the points reset, got %v", err)
+		}
+	}
+}
+
+// testPointsNoFloor tests that a zero floor never holds back queries
+func testPointsNoFloor(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Cleanup(func() { u.fake.SetWarcraftlogsPointsSpent(0) })
+		u.fake.SetWarcraftlogsPointsSpent(3600)
+		client := api.NewWarcraftlogsClient(u.config.WarcraftlogsAPIURL, nil, u.warcraftlogsToken, config.WarcraftlogsConfig{
+			AttendanceWindow: config.DefaultAttendanceWindow,
+		})
+
+		response := getGuildResponse(t, client)
+		if raids := len(response.GuildData.Guild.Attendance.Data); raids != 5 || response.Partial {
+			t.Errorf("Expected the 5 raids of the attendance window, got %d partial %v", raids, response.Partial)
+		}
+	}
+}
+
+// testPointsReadiness tests that the readiness endpoint reports the points budget
+func testPointsReadiness(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Cleanup(func() { u.fake.SetWarcraftlogsPointsSpent(0) })
+		u.fake.SetWarcraftlogsPointsSpent(3500)
+		server := setupHealthServer(t, u, &memorySearchStore{})
+
+		var body struct {
+			Checks map[string]struct {
+				Status string            `json:"status"`
+				Points *api.PointsStatus `json:"points"`
+			} `json:"checks"`
+		}
+		// A low budget holds back expensive queries but the app stays ready
+		if status := getJSON(t, server, "/readyz", &body); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		points := body.Checks["warcraftlogs"].Points
+		if points == nil || !points.Known || points.Remaining != 100 || !points.Low {
+			t.Errorf("Expected a low budget of 100 points, got %+v", points)
+		}
+		if body.Checks["redis"].Points != nil {
+			t.Error("Expected no points budget on the redis check")
+		}
+	}
+}
+
+// testPointsMetrics tests that the budget and the held back queries show up in the metrics
+func testPointsMetrics(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Cleanup(func() { u.fake.SetWarcraftlogsPointsSpent(0) })
+		u.fake.SetWarcraftlogsPointsSpent(3500)
+		getGuildResponse(t, u.warcraftlogsClient(u.warcraftlogsToken))
+
+		recorder := httptest.NewRecorder()
+		metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		body, err := io.ReadAll(recorder.Body)
+		if err != nil {
+			t.Fatalf("Failed to read metrics: %v", err)
+		}
+
+		for _, line := range []string{
+			"wowarmory_warcraftlogs_points_limit 3600",
+			"wowarmory_warcraftlogs_points_spent 3500",
+			`wowarmory_warcraftlogs_queries_degraded_total{query="GetGuildAttendance"}`,
+		} {
+			if !strings.Contains(string(body), line) {
+				t.Errorf("Expected metrics to contain %s", line)
+			}
+		}
+	}
+}
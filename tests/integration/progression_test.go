@@ -0,0 +1,197 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"wowarmory/internal/api"
+	"wowarmory/internal/config"
+	"wowarmory/internal/models"
+)
+
+// TestGuildProgression tests the per boss raid progression of guilds
+func TestGuildProgression(t *testing.T) {
+	u := setupUpstream(t)
+	if u.fake == nil {
+		t.Skip("Progression is only checked against the fake upstream")
+	}
+
+	t.Run("DefaultZone", testProgressionDefaultZone(u))
+	t.Run("Encounters", testProgressionEncounters(u))
+	t.Run("UnknownZone", testProgressionUnknownZone(u))
+	t.Run("GuildNotFound", testProgressionGuildNotFound(u))
+	t.Run("GuildAPI", testGuildAPIProgression(u))
+	t.Run("ProgressionUnavailable", testProgressionUnavailable(u))
+	t.Run("GuildPage", testGuildPageProgression(u))
+}
+
+// getProgression gets the progression of the divine intervention guild
+func getProgression(t *testing.T, u *upstream, zoneID, difficulty int) *api.GuildProgressionResponse {
+	t.Helper()
+
+	client := u.warcraftlogsClient(u.warcraftlogsToken)
+	progression, err := client.GetGuildProgression(context.Background(), "divine intervention", "darkspear", "eu", zoneID, difficulty)
+	if err != nil {
+		t.Fatalf("Failed to get guild progression: %v", err)
+	}
+	response, ok := progression.(*api.GuildProgressionResponse)
+	if !ok {
+		t.Fatalf("Failed to cast guild progression to GuildProgressionResponse: %T", progression)
+	}
+	return response
+}
+
+// testProgressionDefaultZone tests that the most recent raid zone on heroic is picked by default
+func testProgressionDefaultZone(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		response := getProgression(t, u, 0, 0)
+
+		if response.Zone.ID != 42 || response.Difficulty != api.DifficultyHeroic {
+			t.Errorf("Expected zone 42 on heroic, got zone %d on difficulty %d", response.Zone.ID, response.Difficulty)
+		}
+
+		// Dungeons and zones of older expansions are not offered
+		var zones []int
+		for _, zone := range response.Zones {
+			zones = append(zones, zone.ID)
+		}
+		if !slices.Equal(zones, []int{42, 38}) {
+			t.Errorf("Expected the raid zones 42 and 38, got %v", zones)
+		}
+
+		// Both report pages are merged
+		if reports := len(response.ReportData.Reports.Data); reports != 3 {
+			t.Errorf("Expected 3 reports, got %d", reports)
+		}
+	}
+}
+
+// testProgressionEncounters tests the kill status, first kill and best pull per boss
+func testProgressionEncounters(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		progression := models.NewGuildProgression(getProgression(t, u, 42, api.DifficultyHeroic))
+		if progression == nil {
+			t.Fatal("Expected progression, got nil")
+		}
+
+		if progression.Killed != 7 || progression.Total != 8 || progression.DifficultyName != "Heroic" {
+			t.Errorf("Expected 7/8 Heroic, got %d/%d %s", progression.Killed, progression.Total, progression.DifficultyName)
+		}
+
+		// Vexie was farmed later, the first kill is on the second report page
+		vexie := progression.Encounters[0]
+		firstKill := time.Date(2025, 1, 15, 19, 20, 0, 0, time.UTC)
+		if !vexie.Killed || vexie.FirstKill == nil || !vexie.FirstKill.Equal(firstKill) || vexie.Pulls != 3 {
+			t.Errorf("Expected Vexie first killed on %s after 3 pulls, got %+v", firstKill, vexie)
+		}
+
+		gallywix := progression.Encounters[7]
+		if gallywix.Killed || gallywix.FirstKill != nil || gallywix.BestPull != 8.7 || gallywix.Pulls != 2 {
+			t.Errorf("Expected Gallywix not killed with a best pull of 8.7%%, got %+v", gallywix)
+		}
+	}
+}
+
+// testProgressionUnknownZone tests that zones and difficulties that cannot be chosen are reported as not found
+func testProgressionUnknownZone(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
+
+		for _, tc := range []struct {
+			name       string
+			zoneID     int
+			difficulty int
+		}{
+			{"OlderExpansion", 35, api.DifficultyHeroic},
+			{"Dungeon", 39, 10},
+			{"Difficulty", 42, 10},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := client.GetGuildProgression(context.Background(), "divine intervention", "darkspear", "eu", tc.zoneID, tc.difficulty)
+				if !errors.Is(err, api.ErrNotFound) {
+					t.Errorf("Expected ErrNotFound, got %v", err)
+				}
+			})
+		}
+	}
+}
+
+// testProgressionGuildNotFound tests that unknown guilds are reported as not found
+func testProgressionGuildNotFound(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
+
+		_, err := client.GetGuildProgression(context.Background(), "nonexistentguild123456789", "darkspear", "eu", 0, 0)
+		if !errors.Is(err, api.ErrNotFound) {
+			t.Fatalf("Expected ErrNotFound, got %v", err)
+		}
+	}
+}
+
+// testGuildAPIProgression tests that the JSON API includes the progression of the requested zone
+func testGuildAPIProgression(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		server := setupAPIServer(t, u)
+
+		var guild models.GuildData
+		if status := getJSON(t, server, "/api/v1/guilds/eu/darkspear/Divine%20Intervention?zone=42&difficulty=4", &guild); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if guild.Progression == nil || guild.Progression.ZoneID != 42 || len(guild.Progression.Encounters) != 8 {
+			t.Errorf("Expected the progression of zone 42, got %+v", guild.Progression)
+		}
+		if len(guild.MissingSections) != 0 {
+			t.Errorf("Expected no missing sections, got %v", guild.MissingSections)
+		}
+	}
+}
+
+// testProgressionUnavailable tests that a failed progression lookup only marks the progression as missing
+func testProgressionUnavailable(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.failingWarcraftlogsClient(t, "GetRaidZones")
+		server := startAPIServer(t, &config.Config{AssetsDir: "../../assets"}, u, client)
+
+		var guild models.GuildData
+		if status := getJSON(t, server, "/api/v1/guilds/eu/darkspear/Divine%20Intervention", &guild); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if guild.Progression != nil {
+			t.Errorf("Expected no progression, got %+v", guild.Progression)
+		}
+		if !slices.Contains(guild.MissingSections, models.ProgressionSection) {
+			t.Errorf("Expected %q in the missing sections, got %v", models.ProgressionSection, guild.MissingSections)
+		}
+	}
+}
+
+// testGuildPageProgression tests that the guild page shows the progression and zone selector
+func testGuildPageProgression(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		server := setupPageServer(t, u, u.clientSecret)
+
+		resp, err := http.Get(server.URL + "/guild?region=eu&realm=darkspear&guild=divine+intervention&zone=42")
+		if err != nil {
+			t.Fatalf("Failed to request the guild page: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read the guild page: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		for _, want := range []string{"Raid Progression", "7/8 Heroic", "Chrome King Gallywix", "8.7%", "2025-01-15", `value="42" selected`, "Nerub-ar Palace"} {
+			if !strings.Contains(string(body), want) {
+				t.Errorf("Expected the guild page to contain %q", want)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"wowarmory/internal/api"
+	"wowarmory/internal/config"
+	"wowarmory/internal/models"
+)
+
+// TestCharacterRankings tests the Warcraftlogs parses of characters
+func TestCharacterRankings(t *testing.T) {
+	u := setupUpstream(t)
+	if u.warcraftlogsToken == "" {
+		t.Skip("Skipping test due to missing access token")
+	}
+
+	t.Run("GetCharacterRankings", testGetCharacterRankings(u))
+	t.Run("CharacterNotFound", testCharacterRankingsNotFound(u))
+	t.Run("CharacterAPI", testCharacterAPIParses(u))
+	t.Run("ParsesUnavailable", testParsesUnavailable(u))
+	t.Run("CharacterPage", testCharacterPageParses(u))
+}
+
+// testGetCharacterRankings tests that the parses of a character are converted hardest difficulty first
+func testGetCharacterRankings(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		if u.fake == nil {
+			t.Skip("Parses are only checked against the fake upstream")
+		}
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
+
+		response, err := client.GetCharacterRankings(context.Background(), "tempests", "darkspear", "eu")
+		if err != nil {
+			t.Fatalf("Failed to get character rankings: %v", err)
+		}
+
+		rankings := models.NewCharacterRankings(response)
+		if rankings == nil {
+			t.Fatal("Expected parses, got nil")
+		}
+
+		// Normal has no kills and is left out
+		var difficulties []string
+		for _, difficulty := range rankings.Difficulties {
+			difficulties = append(difficulties, difficulty.Difficulty)
+		}
+		if !slices.Equal(difficulties, []string{"Mythic", "Heroic"}) {
+			t.Fatalf("Expected Mythic and Heroic parses, got %v", difficulties)
+		}
+
+		heroic := rankings.Difficulties[1]
+		if heroic.BestAverage != 87 || heroic.MedianAverage != 71 {
+			t.Errorf("Expected heroic averages 87 and 71, got %d and %d", heroic.BestAverage, heroic.MedianAverage)
+		}
+		vexie := heroic.Encounters[0]
+		if !vexie.Killed || vexie.Best != 99 || vexie.BestColor != models.ParseColor(99) || vexie.Kills != 9 {
+			t.Errorf("Unexpected heroic parse %+v", vexie)
+		}
+
+		mythic := rankings.Difficulties[0]
+		if len(mythic.Encounters) != 2 || mythic.Encounters[1].Killed {
+			t.Errorf("Expected the second mythic boss not to be killed, got %+v", mythic.Encounters)
+		}
+	}
+}
+
+// testCharacterRankingsNotFound tests that characters without logs are reported as not found
+func testCharacterRankingsNotFound(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
+
+		_, err := client.GetCharacterRankings(context.Background(), "nonexistentcharacter123", "darkspear", "eu")
+		if !errors.Is(err, api.ErrNotFound) {
+			t.Fatalf("Expected ErrNotFound, got %v", err)
+		}
+	}
+}
+
+// testCharacterAPIParses tests that the JSON API includes the parses of a character
+func testCharacterAPIParses(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		if u.fake == nil {
+			t.Skip("Parses are only checked against the fake upstream")
+		}
+		server := setupAPIServer(t, u)
+
+		var character models.CharacterData
+		if status := getJSON(t, server, "/api/v1/characters/eu/darkspear/tempests", &character); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if character.Parses == nil || len(character.Parses.Difficulties) != 2 {
+			t.Errorf("Expected parses on two difficulties, got %+v", character.Parses)
+		}
+		if len(character.MissingSections) != 0 {
+			t.Errorf("Expected no missing sections, got %v", character.MissingSections)
+		}
+	}
+}
+
+// testParsesUnavailable tests that a Warcraftlogs failure only marks the parses as missing
+func testParsesUnavailable(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		server := startAPIServer(t, &config.Config{AssetsDir: "../../assets"}, u, u.warcraftlogsClient("invalid_token"))
+
+		var character models.CharacterData
+		if status := getJSON(t, server, "/api/v1/characters/eu/darkspear/tempests", &character); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if character.Parses != nil {
+			t.Errorf("Expected no parses, got %+v", character.Parses)
+		}
+		if !slices.Contains(character.MissingSections, models.ParsesSection) {
+			t.Errorf("Expected %q in the missing sections, got %v", models.ParsesSection, character.MissingSections)
+		}
+	}
+}
+
+// testCharacterPageParses tests that the character page shows the parses panel
+func testCharacterPageParses(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		if u.fake == nil {
+			t.Skip("Parses are only checked against the fake upstream")
+		}
+		server := setupPageServer(t, u, u.clientSecret)
+
+		resp, err := http.Get(server.URL + "/character?region=eu&realm=darkspear&character=tempests")
+		if err != nil {
+			t.Fatalf("Failed to request the character page: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read the character page: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		for _, want := range []string{"Raid Parses", "Vexie and the Geargrinders", "Not killed"} {
+			if !strings.Contains(string(body), want) {
+				t.Errorf("Expected the character page to contain %q", want)
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+	"wowarmory/internal/config"
+	"wowarmory/internal/middleware"
+)
+
+// memoryRateLimiter is an in-memory RateLimiter that never refills, so the tests do not need Redis
+type memoryRateLimiter struct {
+	mu    sync.Mutex
+	taken map[string]int
+	err   error
+}
+
+func newMemoryRateLimiter() *memoryRateLimiter {
+	return &memoryRateLimiter{taken: make(map[string]int)}
+}
+
+func (l *memoryRateLimiter) Allow(ctx context.Context, key string, limit int, period time.Duration) (bool, time.Duration, error) {
+	if l.err != nil {
+		return false, 0, l.err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.taken[key] >= limit {
+		return false, period / time.Duration(limit), nil
+	}
+	l.taken[key]++
+	return true, 0, nil
+}
+
+// rateLimitRequest sends a request from the given remote address through the handler
+func rateLimitRequest(handler http.Handler, path, remoteAddr string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// TestRateLimitMiddleware tests the per client IP rate limits
+func TestRateLimitMiddleware(t *testing.T) {
+	cfg := config.RateLimitConfig{
+		Enabled:        true,
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("fdaa::/16")},
+		Limits: map[string]config.RateLimit{
+			config.CharacterRateLimit: {Requests: 2, Period: time.Minute},
+			config.APIRateLimit:       {Requests: 1, Period: 10 * time.Second},
+		},
+		Routes: map[string]string{
+			"/":          config.CharacterRateLimit,
+			"/character": config.CharacterRateLimit,
+			"/api/v1/":   config.APIRateLimit,
+		},
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("LimitsPerRoute", func(t *testing.T) {
+		handler := middleware.RateLimitMiddleware(newMemoryRateLimiter(), cfg, ok)
+
+		for i := 0; i < 2; i++ {
+			if resp := rateLimitRequest(handler, "/character?name=tempests", "203.0.113.1:1234", nil); resp.Code != http.StatusOK {
+				t.Fatalf("Expected request %d to be allowed, got %d", i+1, resp.Code)
+			}
+		}
+
+		resp := rateLimitRequest(handler, "/character?name=tempests", "203.0.113.1:1234", nil)
+		if resp.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status 429, got %d", resp.Code)
+		}
+		if retryAfter := resp.Header().Get("Retry-After"); retryAfter != "30" {
+			t.Errorf("Expected Retry-After 30, got %q", retryAfter)
+		}
+
+		if resp := rateLimitRequest(handler, "/character", "203.0.113.2:1234", nil); resp.Code != http.StatusOK {
+			t.Errorf("Expected other clients to be allowed, got %d", resp.Code)
+		}
+		if resp := rateLimitRequest(handler, "/recent-searches", "203.0.113.1:1234", nil); resp.Code != http.StatusOK {
+			t.Errorf("Expected routes without a limit to be allowed, got %d", resp.Code)
+		}
+	})
+
+	t.Run("FullPage", func(t *testing.T) {
+		handler := middleware.RateLimitMiddleware(newMemoryRateLimiter(), cfg, ok)
+
+		// The full page lookup makes the same upstream calls as the htmx partial and shares its bucket
+		if resp := rateLimitRequest(handler, "/character?region=eu&realm=darkspear&name=tempests", "203.0.113.1:1234", nil); resp.Code != http.StatusOK {
+			t.Fatalf("Expected the partial to be allowed, got %d", resp.Code)
+		}
+		if resp := rateLimitRequest(handler, "/?region=eu&realm=darkspear&character=tempests", "203.0.113.1:1234", nil); resp.Code != http.StatusOK {
+			t.Fatalf("Expected the full page to be allowed, got %d", resp.Code)
+		}
+		for _, path := range []string{"/?region=eu&realm=darkspear&character=tempests", "/character?region=eu&realm=darkspear&name=tempests"} {
+			if resp := rateLimitRequest(handler, path, "203.0.113.1:1234", nil); resp.Code != http.StatusTooManyRequests {
+				t.Errorf("Expected status 429 for %s, got %d", path, resp.Code)
+			}
+		}
+
+		// The landing page and the static assets below the root are not lookups
+		for _, path := range []string{"/", "/assets/css/style.css"} {
+			if resp := rateLimitRequest(handler, path, "203.0.113.1:1234", nil); resp.Code != http.StatusOK {
+				t.Errorf("Expected %s to be allowed, got %d", path, resp.Code)
+			}
+		}
+	})
+
+	t.Run("JSONError", func(t *testing.T) {
+		handler := middleware.RateLimitMiddleware(newMemoryRateLimiter(), cfg, ok)
+
+		rateLimitRequest(handler, "/api/v1/token", "203.0.113.1:1234", nil)
+		resp := rateLimitRequest(handler, "/api/v1/characters/eu/darkspear/tempests", "203.0.113.1:1234", nil)
+		if resp.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status 429, got %d", resp.Code)
+		}
+
+		var body map[string]struct {
+			Code string `json:"code"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode error: %v", err)
+		}
+		if body["error"].Code != middleware.ErrorCodeRateLimited {
+			t.Errorf("Expected error code %q, got %q", middleware.ErrorCodeRateLimited, body["error"].Code)
+		}
+		if retryAfter := resp.Header().Get("Retry-After"); retryAfter != "10" {
+			t.Errorf("Expected Retry-After 10, got %q", retryAfter)
+		}
+	})
+
+	t.Run("LimiterUnavailable", func(t *testing.T) {
+		limiter := newMemoryRateLimiter()
+		limiter.err = errors.New("connection refused")
+		handler := middleware.RateLimitMiddleware(limiter, cfg, ok)
+
+		for i := 0; i < 3; i++ {
+			if resp := rateLimitRequest(handler, "/character", "203.0.113.1:1234", nil); resp.Code != http.StatusOK {
+				t.Fatalf("Expected requests to be allowed when the limiter fails, got %d", resp.Code)
+			}
+		}
+	})
+}
+
+// TestClientIP tests that forwarding headers are only trusted from trusted proxies
+func TestClientIP(t *testing.T) {
+	trustedProxies := []netip.Prefix{
+		netip.MustParsePrefix("fdaa::/16"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		expected   string
+	}{
+		{"Direct", "203.0.113.1:1234", nil, "203.0.113.1"},
+		{"UntrustedFlyClientIP", "203.0.113.1:1234", http.Header{"Fly-Client-Ip": {"198.51.100.1"}}, "203.0.113.1"},
+		{"UntrustedForwardedFor", "203.0.113.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "203.0.113.1"},
+		{"TrustedFlyClientIP", "[fdaa:0:1::2]:1234", http.Header{"Fly-Client-Ip": {"198.51.100.1"}}, "198.51.100.1"},
+		{"TrustedForwardedFor", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"192.0.2.1, 198.51.100.1, 10.0.0.2"}}, "198.51.100.1"},
+		{"SpoofedForwardedFor", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"192.0.2.1", "198.51.100.1"}}, "198.51.100.1"},
+		{"InvalidForwardedFor", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"not-an-ip"}}, "10.0.0.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			req.Header = tc.header
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+
+			if ip := middleware.ClientIP(req, trustedProxies); ip.String() != tc.expected {
+				t.Errorf("Expected client IP %s, got %s", tc.expected, ip)
+			}
+		})
+	}
+}
@@ -39,6 +39,7 @@ func TestRedisIntegration(t *testing.T) {
 	t.Run("NewClient", testNewClient(redisConfig))
 	t.Run("RecordSearch", testRecordSearch(redisConfig))
 	t.Run("GetRecentSearches", testGetRecentSearches(redisConfig))
+	t.Run("RateLimit", testRateLimit(redisConfig))
 }
 
 // testNewClient tests the NewClient function
@@ -137,6 +138,41 @@ func testGetRecentSearches(cfg *config.RedisConfig) func(t *testing.T) {
 	}
 }
 
+// testRateLimit tests the token bucket rate limiter
+func testRateLimit(cfg *config.RedisConfig) func(t *testing.T) {
+	return func(t *testing.T) {
+		client, err := redis.NewClient(cfg)
+		if err != nil {
+			t.Fatalf("Failed to create Redis client: %v", err)
+		}
+		defer client.Close()
+
+		ctx := context.Background()
+		key := "test:" + time.Now().Format("20060102150405.000000")
+
+		for i := 0; i < 3; i++ {
+			allowed, _, err := client.Allow(ctx, key, 3, time.Minute)
+			if err != nil {
+				t.Fatalf("Failed to take token: %v", err)
+			}
+			if !allowed {
+				t.Fatalf("Expected request %d to be allowed", i+1)
+			}
+		}
+
+		allowed, retryAfter, err := client.Allow(ctx, key, 3, time.Minute)
+		if err != nil {
+			t.Fatalf("Failed to take token: %v", err)
+		}
+		if allowed {
+			t.Fatal("Expected the empty bucket to limit the request")
+		}
+		if retryAfter <= 0 || retryAfter > 20*time.Second {
+			t.Errorf("Expected a retry after of up to 20s, got %s", retryAfter)
+		}
+	}
+}
+
 // TestRedisErrorHandling tests error handling in the Redis client
 func TestRedisErrorHandling(t *testing.T) {
 	t.Run("InvalidConnection", func(t *testing.T) {
@@ -0,0 +1,346 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"wowarmory/internal/api"
+	"wowarmory/internal/handlers"
+	"wowarmory/internal/models"
+)
+
+// TestGuildReports tests the Warcraftlogs report browser of guilds
+func TestGuildReports(t *testing.T) {
+	u := setupUpstream(t)
+	if u.fake == nil {
+		t.Skip("Reports are only checked against the fake upstream")
+	}
+
+	t.Run("GetGuildReports", testGetGuildReports(u))
+	t.Run("GuildNotFound", testGuildReportsNotFound(u))
+	t.Run("GetReport", testGetReport(u))
+	t.Run("ReportNotFound", testReportNotFound(u))
+	t.Run("API", testReportsAPI(u))
+	t.Run("Pages", testReportPages(u))
+}
+
+// testGetGuildReports tests that the reports of a guild are listed page by page
+func testGetGuildReports(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
+
+		for _, tc := range []struct {
+			page         int
+			codes        []string
+			hasMorePages bool
+		}{
+			{0, []string{"a1B2c3D4e5F6g7H8", "b2C3d4E5f6G7h8J9"}, true},
+			{2, []string{"c3D4e5F6g7H8j9K1"}, false},
+		} {
+			response, err := client.GetGuildReports(context.Background(), "divine intervention", "darkspear", "eu", tc.page)
+			if err != nil {
+				t.Fatalf("Failed to get page %d of the guild reports: %v", tc.page, err)
+			}
+
+			reports := models.NewGuildReports(response, "eu", "darkspear")
+			if reports == nil {
+				t.Fatalf("Expected reports on page %d, got nil", tc.page)
+			}
+			var codes []string
+			for _, report := range reports.Reports {
+				codes = append(codes, report.Code)
+			}
+			if strings.Join(codes, ",") != strings.Join(tc.codes, ",") || reports.HasMorePages != tc.hasMorePages {
+				t.Errorf("Expected reports %v on page %d, got %v", tc.codes, tc.page, codes)
+			}
+		}
+	}
+}
+
+// testGuildReportsNotFound tests that the reports of unknown guilds are reported as not found
+func testGuildReportsNotFound(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
+
+		_, err := client.GetGuildReports(context.Background(), "nonexistentguild123456789", "darkspear", "eu", 1)
+		if !errors.Is(err, api.ErrNotFound) {
+			t.Fatalf("Expected ErrNotFound, got %v", err)
+		}
+	}
+}
+
+// testGetReport tests the kills, wipes, boss health and durations of the pulls of a report
+func testGetReport(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
+
+		response, err := client.GetReport(context.Background(), "a1B2c3D4e5F6g7H8")
+		if err != nil {
+			t.Fatalf("Failed to get report: %v", err)
+		}
+
+		report := models.NewReport(response)
+		if report == nil {
+			t.Fatal("Expected a report, got nil")
+		}
+		if report.Kills != 1 || report.Wipes != 2 || report.Duration != "3:00:00" {
+			t.Errorf("Expected 1 kill and 2 wipes in 3:00:00, got %d, %d and %s", report.Kills, report.Wipes, report.Duration)
+		}
+		if report.Guild != "Divine Intervention" || report.Realm != "darkspear" || report.Region != "eu" {
+			t.Errorf("Expected the report of Divine Intervention on eu/darkspear, got %s on %s/%s", report.Guild, report.Region, report.Realm)
+		}
+
+		wipe := report.Fights[2]
+		if wipe.Kill || wipe.BossPercentage != 26.5 || wipe.Difficulty != "Heroic" || wipe.Offset != "2:00:00" || wipe.Duration != "7:36" {
+			t.Errorf("Unexpected wipe %+v", wipe)
+		}
+	}
+}
+
+// testReportNotFound tests that unknown reports are reported as not found
+func testReportNotFound(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
+
+		_, err := client.GetReport(context.Background(), "nonexistentreport")
+		if !errors.Is(err, api.ErrNotFound) {
+			t.Fatalf("Expected ErrNotFound, got %v", err)
+		}
+	}
+}
+
+// testReportsAPI tests the report endpoints of the JSON API
+func testReportsAPI(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		server := setupAPIServer(t, u)
+
+		var reports models.GuildReports
+		if status := getJSON(t, server, "/api/v1/guilds/eu/darkspear/Divine%20Intervention/reports?page=2", &reports); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if reports.Page != 2 || len(reports.Reports) != 1 {
+			t.Errorf("Expected the single report of page 2, got %+v", reports)
+		}
+
+		var report models.Report
+		if status := getJSON(t, server, "/api/v1/reports/a1B2c3D4e5F6g7H8", &report); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if len(report.Fights) != 3 {
+			t.Errorf("Expected 3 pulls, got %d", len(report.Fights))
+		}
+
+		// Warcraftlogs answers some unknown reports with a null report and others with a GraphQL error
+		for _, code := range []string{"nonexistentreport", "xyztypo1234"} {
+			var body map[string]handlers.APIError
+			if status := getJSON(t, server, "/api/v1/reports/"+code, &body); status != http.StatusNotFound {
+				t.Fatalf("Expected status 404 for %s, got %d", code, status)
+			}
+			if body["error"].Code != handlers.ErrorCodeNotFound {
+				t.Errorf("Expected error code %q for %s, got %q", handlers.ErrorCodeNotFound, code, body["error"].Code)
+			}
+		}
+	}
+}
+
+// testReportPages tests the report list and report pages
+func testReportPages(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		server := setupPageServer(t, u, u.clientSecret)
+
+		tests := []struct {
+			name   string
+			path   string
+			status int
+			want   []string
+		}{
+			{
+				name:   "GuildReports",
+				path:   "/guild-reports?region=eu&realm=darkspear&guild=divine+intervention",
+				status: http.StatusOK,
+				want:   []string{"Undermine Heroic", "/report?code=a1B2c3D4e5F6g7H8", "Page 1 of 2", "Older"},
+			},
+			{
+				name:   "GuildReportsNotFound",
+				path:   "/guild-reports?region=eu&realm=darkspear&guild=nonexistentguild123456789",
+				status: http.StatusNotFound,
+				want:   []string{"The requested guild could not be found."},
+			},
+			{
+				name:   "Report",
+				path:   "/report?code=a1B2c3D4e5F6g7H8",
+				status: http.StatusOK,
+				want:   []string{"Boss Pulls", "Chrome King Gallywix", "26.5%", "Wipe", "Kills: 1", "Wipes: 2"},
+			},
+			{
+				name:   "ReportNotFound",
+				path:   "/report?code=nonexistentreport",
+				status: http.StatusNotFound,
+				want:   []string{"The requested report could not be found."},
+			},
+			{
+				name:   "ReportDoesNotExist",
+				path:   "/report?code=xyztypo1234",
+				status: http.StatusNotFound,
+				want:   []string{"The requested report could not be found."},
+			},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				status, body := getPage(t, server, tc.path)
+				if status != tc.status {
+					t.Fatalf("Expected status %d, got %d", tc.status, status)
+				}
+				for _, want := range tc.want {
+					if !strings.Contains(body, want) {
+						t.Errorf("Expected the page to contain %q", want)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestReportTables tests the summary tables of the fights of reports
+func TestReportTables(t *testing.T) {
+	u := setupUpstream(t)
+	if u.fake == nil {
+		t.Skip("Report tables are only checked against the fake upstream")
+	}
+
+	t.Run("DamageDone", testDamageDoneTable(u))
+	t.Run("Deaths", testDeathsTable(u))
+	t.Run("NotFound", testReportTableNotFound(u))
+	t.Run("API", testReportTableAPI(u))
+	t.Run("Page", testReportTablePage(u))
+}
+
+// getReportTable gets a table of the Gallywix wipe of the fixture report
+func getReportTable(t *testing.T, u *upstream, dataType string) *models.ReportTable {
+	t.Helper()
+
+	client := u.warcraftlogsClient(u.warcraftlogsToken)
+	response, err := client.GetReportTable(context.Background(), "a1B2c3D4e5F6g7H8", 9, dataType)
+	if err != nil {
+		t.Fatalf("Failed to get the %s table: %v", dataType, err)
+	}
+
+	table := models.NewReportTable(response)
+	if table == nil {
+		t.Fatalf("Expected a %s table, got nil", dataType)
+	}
+	return table
+}
+
+// testDamageDoneTable tests the amount, per second and percent of the players, highest amount first
+func testDamageDoneTable(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		table := getReportTable(t, u, api.TableDamageDone)
+
+		if table.Fight != "Chrome King Gallywix" || table.Duration != "7:36" || len(table.Rows) != 5 {
+			t.Fatalf("Expected 5 players on Chrome King Gallywix for 7:36, got %d on %s for %s", len(table.Rows), table.Fight, table.Duration)
+		}
+
+		top := table.Rows[0]
+		if top.Player != "Velaris" || top.Class != "Mage" || top.Spec != "Fire" {
+			t.Errorf("Expected Velaris the Fire Mage on top, got %+v", top)
+		}
+		if top.Amount != 455500000 || top.PerSecond != 1000000 || top.Percent != 39.2 {
+			t.Errorf("Expected 455500000 damage at 1000000 per second and 39.2%%, got %+v", top)
+		}
+		if last := table.Rows[len(table.Rows)-1]; last.Player != "Thornvale" {
+			t.Errorf("Expected Thornvale last, got %s", last.Player)
+		}
+	}
+}
+
+// testDeathsTable tests the time since the pull and killing blow of deaths, earliest death first
+func testDeathsTable(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		table := getReportTable(t, u, api.TableDeaths)
+
+		if len(table.Rows) != 2 {
+			t.Fatalf("Expected 2 deaths, got %d", len(table.Rows))
+		}
+		// The fixture lists the later death first
+		death := table.Rows[0]
+		if death.Player != "Velaris" || death.Time != "5:01" || death.KillingBlow != "Big Bad Bomb" || death.Amount != 0 {
+			t.Errorf("Expected Velaris to die to Big Bad Bomb at 5:01, got %+v", death)
+		}
+		if death := table.Rows[1]; death.Player != "Tempests" || death.Time != "6:52" {
+			t.Errorf("Expected Tempests to die second at 6:52, got %+v", death)
+		}
+	}
+}
+
+// testReportTableNotFound tests that unknown fights and data types are reported as not found
+func testReportTableNotFound(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
+
+		for _, tc := range []struct {
+			name     string
+			fightID  int
+			dataType string
+		}{
+			{"Fight", 42, api.TableDamageDone},
+			{"DataType", 9, "Threat"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := client.GetReportTable(context.Background(), "a1B2c3D4e5F6g7H8", tc.fightID, tc.dataType)
+				if !errors.Is(err, api.ErrNotFound) {
+					t.Errorf("Expected ErrNotFound, got %v", err)
+				}
+			})
+		}
+	}
+}
+
+// testReportTableAPI tests the report table endpoint of the JSON API
+func testReportTableAPI(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		server := setupAPIServer(t, u)
+
+		var table models.ReportTable
+		if status := getJSON(t, server, "/api/v1/reports/a1B2c3D4e5F6g7H8/fights/9/Healing", &table); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if table.DataType != api.TableHealing || len(table.Rows) != 3 || table.Rows[0].Player != "Holyfrost" {
+			t.Errorf("Expected Holyfrost to top the healing, got %+v", table)
+		}
+
+		for _, path := range []string{
+			"/api/v1/reports/a1B2c3D4e5F6g7H8/fights/9/Threat",
+			"/api/v1/reports/a1B2c3D4e5F6g7H8/fights/gallywix/Healing",
+		} {
+			var body map[string]handlers.APIError
+			if status := getJSON(t, server, path, &body); status != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", path, status)
+			}
+		}
+	}
+}
+
+// testReportTablePage tests that the report page shows the table of the chosen fight
+func testReportTablePage(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		server := setupPageServer(t, u, u.clientSecret)
+
+		status, body := getPage(t, server, "/report?code=a1B2c3D4e5F6g7H8&fight=9&table=Deaths")
+		if status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		for _, want := range []string{"Chrome King Gallywix (7:36)", "Big Bad Bomb", "Killing Blow", "sortTable(this)"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Expected the report page to contain %q", want)
+			}
+		}
+
+		if status, _ := getPage(t, server, "/report?code=a1B2c3D4e5F6g7H8&fight=9&table=Threat"); status != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an unknown table, got %d", status)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"wowarmory/internal/api"
+)
+
+// TestUpstreamRetry tests the retries of failed upstream calls against injected failures
+func TestUpstreamRetry(t *testing.T) {
+	u := setupUpstream(t)
+	if u.fake == nil {
+		t.Skip("Retries are only checked against the fake upstream")
+	}
+
+	t.Run("RetriesServerErrors", testRetriesServerErrors(u))
+	t.Run("GivesUpAfterMaxRetries", testGivesUpAfterMaxRetries(u))
+	t.Run("LongRetryAfterNotWaited", testLongRetryAfterNotWaited(u))
+	t.Run("RetriesGraphQL", testRetriesGraphQL(u))
+	t.Run("BudgetExhausted", testBudgetExhausted(u))
+	t.Run("BudgetReleasedOnCancel", testBudgetReleasedOnCancel(u))
+}
+
+// testRetriesServerErrors tests that 503s are retried with backoff until the call succeeds
+func testRetriesServerErrors(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := api.NewTokenClient(u.config.BlizzardAPIURL, u.tokenProvider(u.clientID, u.clientSecret), nil)
+
+		u.fake.FailNext(2, http.StatusServiceUnavailable, "")
+		if _, err := client.GetTokenPrice(context.Background(), "eu"); err != nil {
+			t.Fatalf("Expected the call to succeed after retries, got %v", err)
+		}
+	}
+}
+
+// testGivesUpAfterMaxRetries tests that the last failure is returned once the retries are used up
+func testGivesUpAfterMaxRetries(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := api.NewTokenClient(u.config.BlizzardAPIURL, u.tokenProvider(u.clientID, u.clientSecret), nil)
+
+		u.fake.FailNext(3, http.StatusTooManyRequests, "0")
+		if _, err := client.GetTokenPrice(context.Background(), "eu"); err == nil {
+			t.Fatal("Expected an error after three failed attempts")
+		}
+		if _, err := client.GetTokenPrice(context.Background(), "eu"); err != nil {
+			t.Fatalf("Expected the next call to succeed, got %v", err)
+		}
+	}
+}
+
+// testLongRetryAfterNotWaited tests that a Retry-After beyond the maximum fails right away
+func testLongRetryAfterNotWaited(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := api.NewTokenClient(u.config.BlizzardAPIURL, u.tokenProvider(u.clientID, u.clientSecret), nil)
+
+		u.fake.FailNext(1, http.StatusTooManyRequests, "60")
+		start := time.Now()
+		if _, err := client.GetTokenPrice(context.Background(), "eu"); err == nil {
+			t.Fatal("Expected an error for a long Retry-After")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected the call to fail without waiting, took %s", elapsed)
+		}
+	}
+}
+
+// testRetriesGraphQL tests that Warcraftlogs queries are retried even though they are POSTs
+func testRetriesGraphQL(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
+
+		u.fake.FailNext(1, http.StatusBadGateway, "0")
+		if err := client.Ping(context.Background()); err != nil {
+			t.Fatalf("Expected the query to succeed after a retry, got %v", err)
+		}
+	}
+}
+
+// testBudgetExhausted tests that calls beyond the region budget fail with a typed error
+func testBudgetExhausted(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		budget := api.NewRegionBudget(u.config.BlizzardAPIURL, 1, time.Minute)
+		client := api.NewTokenClient(u.config.BlizzardAPIURL, u.tokenProvider(u.clientID, u.clientSecret), budget)
+
+		if _, err := client.GetTokenPrice(context.Background(), "eu"); err != nil {
+			t.Fatalf("Expected the first call to fit the budget, got %v", err)
+		}
+
+		_, err := client.GetTokenPrice(context.Background(), "eu")
+		if !errors.Is(err, api.ErrBudgetExhausted) {
+			t.Fatalf("Expected ErrBudgetExhausted, got %v", err)
+		}
+		var budgetErr *api.BudgetExhaustedError
+		if !errors.As(err, &budgetErr) || budgetErr.Region != "eu" || budgetErr.RetryAfter <= 0 {
+			t.Errorf("Expected a budget error for region eu with a retry after, got %+v", budgetErr)
+		}
+
+		if _, err := client.GetTokenPrice(context.Background(), "us"); err != nil {
+			t.Errorf("Expected other regions to have their own budget, got %v", err)
+		}
+	}
+}
+
+// testBudgetReleasedOnCancel tests that calls cancelled while waiting for the budget give their token back
+func testBudgetReleasedOnCancel(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		// A token every 1.5s, so the next one is always within the longest wait
+		budget := api.NewRegionBudget(u.config.BlizzardAPIURL, 1, 1500*time.Millisecond)
+		url := strings.ReplaceAll(u.config.BlizzardAPIURL, "{region}", "eu") + "/data/wow/token/index"
+
+		if err := budget.Wait(context.Background(), url); err != nil {
+			t.Fatalf("Expected the first call to fit the budget, got %v", err)
+		}
+
+		// Without giving the tokens back, the third call would be more than the longest wait away
+		for i := 0; i < 3; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			err := budget.Wait(ctx, url)
+			cancel()
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Expected call %d to wait until it is cancelled, got %v", i+1, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"wowarmory/internal/api"
+	"wowarmory/internal/config"
+	"wowarmory/internal/handlers"
+	"wowarmory/internal/interfaces"
+	"wowarmory/internal/models"
+	"wowarmory/internal/router"
+)
+
+// unavailableRoster is a BlizzardAPI whose guild rosters fail to load
+type unavailableRoster struct {
+	interfaces.BlizzardAPI
+}
+
+func (c *unavailableRoster) GetGuildRoster(ctx context.Context, region, realm, guild string) (interface{}, error) {
+	return nil, &api.Error{Kind: api.ErrUpstream, Client: c.GetClientName(), StatusCode: http.StatusServiceUnavailable}
+}
+
+// TestGuildRoster tests the Blizzard guild roster and its merge with the Warcraftlogs guild data
+func TestGuildRoster(t *testing.T) {
+	u := setupUpstream(t)
+	if u.fake == nil {
+		t.Skip("The guild roster is only checked against the fake upstream")
+	}
+
+	t.Run("Roster", testRoster(u))
+	t.Run("ApostropheSlug", testRosterApostropheSlug(u))
+	t.Run("RosterNotFound", testRosterNotFound(u))
+	t.Run("Merge", testRosterMerge(u))
+	t.Run("GuildAPI", testGuildAPIRoster(u))
+	t.Run("RosterUnavailable", testRosterUnavailable(u))
+	t.Run("GuildPage", testGuildPageRoster(u))
+}
+
+// testRoster tests that every member is fetched with their rank, level, class and race IDs
+func testRoster(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.blizzardClient(u.clientID, u.clientSecret)
+
+		roster, err := client.GetGuildRoster(context.Background(), "eu", "darkspear", "divine intervention")
+		if err != nil {
+			t.Fatalf("Failed to get guild roster: %v", err)
+		}
+		response, ok := roster.(*api.GuildRosterResponse)
+		if !ok {
+			t.Fatalf("Failed to cast guild roster to GuildRosterResponse: %T", roster)
+		}
+		if response.Guild.Name != "Divine Intervention" || len(response.Members) != 7 {
+			t.Errorf("Expected the 7 members of Divine Intervention, got %d of %s", len(response.Members), response.Guild.Name)
+		}
+	}
+}
+
+// testRosterApostropheSlug tests that apostrophes are dropped from the guild slug like Blizzard does
+func testRosterApostropheSlug(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.blizzardClient(u.clientID, u.clientSecret)
+
+		roster, err := client.GetGuildRoster(context.Background(), "eu", "darkspear", "Sin'dorei Vanguard")
+		if err != nil {
+			t.Fatalf("Failed to get guild roster: %v", err)
+		}
+		response, ok := roster.(*api.GuildRosterResponse)
+		if !ok {
+			t.Fatalf("Failed to cast guild roster to GuildRosterResponse: %T", roster)
+		}
+		if response.Guild.Name != "Sin'dorei Vanguard" || len(response.Members) != 2 {
+			t.Errorf("Expected the 2 members of Sin'dorei Vanguard, got %d of %s", len(response.Members), response.Guild.Name)
+		}
+	}
+}
+
+// testRosterNotFound tests that guilds unknown to Blizzard are reported as not found
+func testRosterNotFound(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		client := u.blizzardClient(u.clientID, u.clientSecret)
+
+		_, err := client.GetGuildRoster(context.Background(), "eu", "darkspear", "nonexistentguild123456789")
+		if !errors.Is(err, api.ErrNotFound) {
+			t.Fatalf("Expected ErrNotFound, got %v", err)
+		}
+	}
+}
+
+// testRosterMerge tests that the roster gets the Warcraftlogs attendance and the recent raiders get their rank
+func testRosterMerge(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		guild, err := models.NewGuildData(getGuildResponse(t, u.warcraftlogsClient(u.warcraftlogsToken)), "eu", "darkspear")
+		if err != nil {
+			t.Fatalf("Failed to create guild data: %v", err)
+		}
+		roster, err := u.blizzardClient(u.clientID, u.clientSecret).GetGuildRoster(context.Background(), "eu", "darkspear", "divine intervention")
+		if err != nil {
+			t.Fatalf("Failed to get guild roster: %v", err)
+		}
+		guild.AddRoster(roster)
+
+		if guild.MemberCount != 7 || len(guild.Roster) != 7 {
+			t.Fatalf("Expected 7 members on the roster, got %d with a member count of %d", len(guild.Roster), guild.MemberCount)
+		}
+
+		master := guild.Roster[0]
+		if master.Name != "Tempests" || master.RankName != "Guild Master" || master.Class != "Shaman" || master.Race != "Orc" || master.Faction != "Horde" {
+			t.Errorf("Expected Tempests the Orc Shaman to lead the roster, got %+v", master)
+		}
+
+		members := make(map[string]models.RosterMember)
+		for _, member := range guild.Roster {
+			members[member.Name] = member
+		}
+		kazgrim := members["Kazgrim"]
+		if !kazgrim.Raider || kazgrim.Attendance == nil || *kazgrim.Attendance != 60 || kazgrim.Race != "Mag'har Orc" {
+			t.Errorf("Expected Kazgrim the Mag'har Orc raider at 60%% attendance, got %+v", kazgrim)
+		}
+
+		// Members without a faction on the roster get the faction of the guild
+		coinpurse := members["Coinpurse"]
+		if coinpurse.Raider || coinpurse.Attendance != nil || coinpurse.Level != 42 || coinpurse.Class != "Rogue" || coinpurse.Faction != "Horde" {
+			t.Errorf("Expected Coinpurse the level 42 Rogue without attendance, got %+v", coinpurse)
+		}
+
+		for _, raider := range guild.Members {
+			if raider.Rank == "" {
+				t.Errorf("Expected recent raider %s to have a rank", raider.Name)
+			}
+		}
+
+		if ranks := guild.RosterRanks(); !slices.Equal(ranks, []string{"Guild Master", "Rank 1", "Rank 3", "Rank 5"}) {
+			t.Errorf("Expected the ranks in order, got %v", ranks)
+		}
+		if classes := guild.RosterClasses(); !slices.Equal(classes, []string{"Druid", "Evoker", "Mage", "Paladin", "Rogue", "Shaman", "Warrior"}) {
+			t.Errorf("Expected the classes in alphabetical order, got %v", classes)
+		}
+	}
+}
+
+// testGuildAPIRoster tests that the JSON API includes the roster
+func testGuildAPIRoster(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		server := setupAPIServer(t, u)
+
+		var guild models.GuildData
+		if status := getJSON(t, server, "/api/v1/guilds/eu/darkspear/Divine%20Intervention", &guild); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if len(guild.Roster) != 7 || guild.MemberCount != 7 {
+			t.Errorf("Expected 7 members on the roster, got %d", len(guild.Roster))
+		}
+		if len(guild.MissingSections) != 0 {
+			t.Errorf("Expected no missing sections, got %v", guild.MissingSections)
+		}
+	}
+}
+
+// testRosterUnavailable tests that a failed roster lookup only marks the roster as missing
+func testRosterUnavailable(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		cfg := &config.Config{AssetsDir: "../../assets"}
+		tokens := u.tokenProvider(u.clientID, u.clientSecret)
+		base := handlers.NewBaseHandler(cfg, &memorySearchStore{}, nil)
+		r := router.New(cfg)
+		r.SetupHandlers([]interfaces.Handler{handlers.NewAPIHandler(base,
+			&unavailableRoster{BlizzardAPI: api.NewBlizzardClient(u.config.BlizzardAPIURL, tokens, nil)},
+			u.warcraftlogsClient(u.warcraftlogsToken),
+			api.NewTokenClient(u.config.BlizzardAPIURL, tokens, nil),
+		)})
+		server := httptest.NewServer(r)
+		defer server.Close()
+
+		var guild models.GuildData
+		if status := getJSON(t, server, "/api/v1/guilds/eu/darkspear/Divine%20Intervention", &guild); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		if guild.Roster != nil || len(guild.Members) == 0 {
+			t.Errorf("Expected the recent raiders without a roster, got %d raiders and %d roster members", len(guild.Members), len(guild.Roster))
+		}
+		if !slices.Contains(guild.MissingSections, models.RosterSection) {
+			t.Errorf("Expected %q in the missing sections, got %v", models.RosterSection, guild.MissingSections)
+		}
+	}
+}
+
+// testGuildPageRoster tests that the guild page shows the roster with its rank and class filters
+func testGuildPageRoster(u *upstream) func(t *testing.T) {
+	return func(t *testing.T) {
+		server := setupPageServer(t, u, u.clientSecret)
+
+		status, body := getPage(t, server, "/guild-lookup?region=eu&realm=darkspear&guild=divine+intervention")
+		if status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", status)
+		}
+		for _, want := range []string{"Guild Roster", "7 members", "Coinpurse", "Mag&#39;har Orc", `data-rank="Rank 5"`, `<option value="Evoker">`, "filterRoster(this)"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Expected the guild page to contain %q", want)
+			}
+		}
+
+		// The htmx partial renders the guild data itself instead of the layout data
+		status, body = getPage(t, server, "/guild?region=eu&realm=darkspear&guild=divine+intervention")
+		if status != http.StatusOK || !strings.Contains(body, `<option value="Guild Master">`) {
+			t.Errorf("Expected the guild partial to have the rank filter, got status %d", status)
+		}
+	}
+}
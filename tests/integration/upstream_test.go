@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"wowarmory/internal/api"
+	"wowarmory/internal/config"
+	"wowarmory/internal/fakeupstream"
+
+	"github.com/joho/godotenv"
+)
+
+// upstream holds the upstream URLs and credentials the integration tests run against
+type upstream struct {
+	config                   config.UpstreamConfig
+	clientID                 string
+	clientSecret             string
+	warcraftlogsClientID     string
+	warcraftlogsClientSecret string
+	warcraftlogsToken        string
+
+	// fake is the fake upstream server, nil when testing against the live APIs
+	fake *fakeupstream.Server
+}
+
+// setupUpstream returns the upstream to test against. Tests run offline against the fake
+// upstream server unless INTEGRATION_LIVE=true, which uses the real APIs and the
+// credentials from the environment.
+func setupUpstream(t *testing.T) *upstream {
+	t.Helper()
+
+	if os.Getenv("INTEGRATION_LIVE") != "true" {
+		fake := fakeupstream.NewServer()
+		t.Cleanup(fake.Close)
+
+		return &upstream{
+			config:                   fake.UpstreamConfig(),
+			clientID:                 fakeupstream.ClientID,
+			clientSecret:             fakeupstream.ClientSecret,
+			warcraftlogsClientID:     fakeupstream.WarcraftlogsClientID,
+			warcraftlogsClientSecret: fakeupstream.WarcraftlogsClientSecret,
+			warcraftlogsToken:        fakeupstream.WarcraftlogsToken,
+			fake:                     fake,
+		}
+	}
+
+	// Load .env file if it exists
+	if err := godotenv.Load(); err != nil {
+		t.Log("Error loading .env file, continuing without it. Ignore this if running as container.")
+	}
+
+	return &upstream{
+		config: config.UpstreamConfig{
+			BlizzardOAuthURL:     config.DefaultBlizzardOAuthURL,
+			BlizzardAPIURL:       config.DefaultBlizzardAPIURL,
+			WarcraftlogsOAuthURL: config.DefaultWarcraftlogsOAuthURL,
+			WarcraftlogsAPIURL:   config.DefaultWarcraftlogsAPIURL,
+		},
+		clientID:                 os.Getenv("CLIENT_ID"),
+		clientSecret:             os.Getenv("CLIENT_SECRET"),
+		warcraftlogsClientID:     os.Getenv("WARCRAFTLOGS_CLIENT_ID"),
+		warcraftlogsClientSecret: os.Getenv("WARCRAFTLOGS_CLIENT_SECRET"),
+		warcraftlogsToken:        os.Getenv("WARCRAFTLOGS_API_TOKEN"),
+	}
+}
+
+// tokenProvider creates a Blizzard access token provider with the given credentials
+func (u *upstream) tokenProvider(clientID, clientSecret string) *api.AccessTokenProvider {
+	return api.NewAccessTokenProvider(u.config.BlizzardOAuthURL, clientID, clientSecret)
+}
+
+// blizzardClient creates a Blizzard API client with the given credentials
+func (u *upstream) blizzardClient(clientID, clientSecret string) *api.BlizzardClient {
+	return api.NewBlizzardClient(u.config.BlizzardAPIURL, u.tokenProvider(clientID, clientSecret), nil)
+}
+
+// warcraftlogsClient creates a Warcraftlogs API client with the given static token
+func (u *upstream) warcraftlogsClient(accessToken string) *api.WarcraftlogsClient {
+	return api.NewWarcraftlogsClient(u.config.WarcraftlogsAPIURL, nil, accessToken, u.warcraftlogsConfig())
+}
+
+// warcraftlogsOAuthClient creates a Warcraftlogs API client with the given client credentials and static fallback token
+func (u *upstream) warcraftlogsOAuthClient(clientID, clientSecret, staticToken string) *api.WarcraftlogsClient {
+	tokens := api.NewWarcraftlogsTokenProvider(u.config.WarcraftlogsOAuthURL, clientID, clientSecret)
+	return api.NewWarcraftlogsClient(u.config.WarcraftlogsAPIURL, tokens, staticToken, u.warcraftlogsConfig())
+}
+
+// failingWarcraftlogsClient creates a Warcraftlogs API client with the static token whose queries of the
+// given operation fail with 503 while all other queries reach the fake upstream
+func (u *upstream) failingWarcraftlogsClient(t *testing.T, operation string) *api.WarcraftlogsClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if bytes.Contains(body, []byte("query "+operation)) {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		u.fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	apiURL := strings.Replace(u.config.WarcraftlogsAPIURL, u.fake.URL, server.URL, 1)
+	return api.NewWarcraftlogsClient(apiURL, nil, u.warcraftlogsToken, u.warcraftlogsConfig())
+}
+
+// warcraftlogsConfig returns the Warcraftlogs configuration of the clients, the defaults of the app
+func (u *upstream) warcraftlogsConfig() config.WarcraftlogsConfig {
+	return config.WarcraftlogsConfig{
+		AttendanceWindow: config.DefaultAttendanceWindow,
+		PointsFloor:      config.DefaultWarcraftlogsPointsFloor,
+	}
+}
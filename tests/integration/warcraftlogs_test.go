@@ -2,36 +2,27 @@ package integration
 
 import (
 	"context"
-	"fmt"
-	"os"
+	"errors"
 	"testing"
 	"wowarmory/internal/api"
-
-	"github.com/joho/godotenv"
 )
 
 // TestWarcraftlogsAPIIntegration tests the integration with the Warcraftlogs API
 func TestWarcraftlogsAPIIntegration(t *testing.T) {
-	// Load .env file if it exists
-	err := godotenv.Load()
-	if err != nil {
-		fmt.Println("Warning: Error loading .env file, continuing without it. Ignore this if running as container.")
-	}
+	u := setupUpstream(t)
 
-	// Get Warcraftlogs API token from environment variables
-	accessToken := os.Getenv("WARCRAFTLOGS_API_TOKEN")
-	if accessToken == "" {
+	if u.warcraftlogsToken == "" {
 		t.Fatal("WARCRAFTLOGS_API_TOKEN environment variable must be set")
 	}
 
-	t.Run("GetGuild", testGetGuild(accessToken))
+	t.Run("GetGuild", testGetGuild(u))
 }
 
 // testGetGuild tests the GetGuild function
-func testGetGuild(accessToken string) func(t *testing.T) {
+func testGetGuild(u *upstream) func(t *testing.T) {
 	return func(t *testing.T) {
 		// Create a new Warcraftlogs API client
-		client := api.NewWarcraftlogsClient(accessToken)
+		client := u.warcraftlogsClient(u.warcraftlogsToken)
 
 		// Test parameters - using a known guild
 		serverRegion := "eu"
@@ -76,11 +67,10 @@ func testGetGuild(accessToken string) func(t *testing.T) {
 
 // TestWarcraftlogsAPIErrorHandling tests error handling in the Warcraftlogs API client
 func TestWarcraftlogsAPIErrorHandling(t *testing.T) {
-	// Load .env file if it exists
-	_ = godotenv.Load()
+	u := setupUpstream(t)
 
 	// Get valid access token
-	accessToken := os.Getenv("WARCRAFTLOGS_API_TOKEN")
+	accessToken := u.warcraftlogsToken
 
 	t.Run("InvalidGuildName", func(t *testing.T) {
 		if accessToken == "" {
@@ -88,15 +78,15 @@ func TestWarcraftlogsAPIErrorHandling(t *testing.T) {
 		}
 
 		// Create a client with valid token
-		client := api.NewWarcraftlogsClient(accessToken)
+		client := u.warcraftlogsClient(accessToken)
 
 		// Set up context
 		ctx := context.Background()
 
 		// Attempt to get a non-existent guild
 		_, err := client.GetGuild(ctx, "nonexistentguild123456789", "darkspear", "eu")
-		if err != nil {
-			t.Fatal("Expected error with non-existent guild, but got none")
+		if !errors.Is(err, api.ErrNotFound) {
+			t.Fatalf("Expected ErrNotFound with non-existent guild, got %v", err)
 		}
 		t.Logf("Got expected error with non-existent guild: %v", err)
 	})
@@ -107,7 +97,7 @@ func TestWarcraftlogsAPIErrorHandling(t *testing.T) {
 		}
 
 		// Create a client with valid token
-		client := api.NewWarcraftlogsClient(accessToken)
+		client := u.warcraftlogsClient(accessToken)
 
 		// Set up context
 		ctx := context.Background()
@@ -122,15 +112,15 @@ func TestWarcraftlogsAPIErrorHandling(t *testing.T) {
 
 	t.Run("InvalidToken", func(t *testing.T) {
 		// Create a client with invalid token
-		client := api.NewWarcraftlogsClient("invalid_token")
+		client := u.warcraftlogsClient("invalid_token")
 
 		// Set up context
 		ctx := context.Background()
 
 		// Attempt to get guild data
 		_, err := client.GetGuild(ctx, "divine intervention", "darkspear", "eu")
-		if err != nil {
-			t.Fatal("Expected error with invalid token, but got none")
+		if !errors.Is(err, api.ErrUnauthorized) {
+			t.Fatalf("Expected ErrUnauthorized with invalid token, got %v", err)
 		}
 		t.Logf("Got expected error with invalid token: %v", err)
 	})
@@ -141,15 +131,15 @@ func TestWarcraftlogsAPIErrorHandling(t *testing.T) {
 		}
 
 		// Create a client with valid token
-		client := api.NewWarcraftlogsClient(accessToken)
+		client := u.warcraftlogsClient(accessToken)
 
 		// Set up context
 		ctx := context.Background()
 
 		// Test with empty guild name
 		_, err := client.GetGuild(ctx, "", "darkspear", "eu")
-		if err != nil {
-			t.Fatal("Expected error with empty guild name, but got none")
+		if !errors.Is(err, api.ErrNotFound) {
+			t.Fatalf("Expected ErrNotFound with empty guild name, got %v", err)
 		}
 
 		// Test with empty server slug
@@ -166,3 +156,73 @@ func TestWarcraftlogsAPIErrorHandling(t *testing.T) {
 		}
 	})
 }
+
+// TestWarcraftlogsOAuth tests authenticating with Warcraftlogs client credentials instead of a static token
+func TestWarcraftlogsOAuth(t *testing.T) {
+	u := setupUpstream(t)
+
+	t.Run("ClientCredentials", func(t *testing.T) {
+		if u.warcraftlogsClientID == "" || u.warcraftlogsClientSecret == "" {
+			t.Skip("Skipping test due to missing Warcraftlogs client credentials")
+		}
+
+		client := u.warcraftlogsOAuthClient(u.warcraftlogsClientID, u.warcraftlogsClientSecret, "")
+		if _, err := client.GetGuild(context.Background(), "divine intervention", "darkspear", "eu"); err != nil {
+			t.Fatalf("Failed to get guild data with client credentials: %v", err)
+		}
+	})
+
+	t.Run("RefreshOnUnauthorized", func(t *testing.T) {
+		if u.fake == nil {
+			t.Skip("Token revocation is only checked against the fake upstream")
+		}
+
+		client := u.warcraftlogsOAuthClient(u.warcraftlogsClientID, u.warcraftlogsClientSecret, "")
+		ctx := context.Background()
+		before := u.fake.WarcraftlogsTokenRequests()
+
+		if _, err := client.GetGuild(ctx, "divine intervention", "darkspear", "eu"); err != nil {
+			t.Fatalf("Failed to get guild data: %v", err)
+		}
+		u.fake.RevokeWarcraftlogsTokens()
+		if _, err := client.GetGuild(ctx, "divine intervention", "darkspear", "eu"); err != nil {
+			t.Fatalf("Expected a fresh token after the 401, got %v", err)
+		}
+
+		if requests := u.fake.WarcraftlogsTokenRequests() - before; requests != 2 {
+			t.Errorf("Expected 2 token requests, got %d", requests)
+		}
+	})
+
+	t.Run("StaticTokenFallback", func(t *testing.T) {
+		if u.warcraftlogsToken == "" {
+			t.Skip("Skipping test due to missing access token")
+		}
+
+		client := u.warcraftlogsOAuthClient("invalid_client_id", "invalid_client_secret", u.warcraftlogsToken)
+		var before int64
+		if u.fake != nil {
+			before = u.fake.WarcraftlogsTokenRejections()
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := client.GetGuild(context.Background(), "divine intervention", "darkspear", "eu"); err != nil {
+				t.Fatalf("Expected the static token to be used when the token endpoint fails, got %v", err)
+			}
+		}
+
+		// The failure is remembered, the second query goes straight to the static token
+		if u.fake != nil {
+			if rejections := u.fake.WarcraftlogsTokenRejections() - before; rejections != 1 {
+				t.Errorf("Expected 1 rejected token request, got %d", rejections)
+			}
+		}
+	})
+
+	t.Run("InvalidCredentials", func(t *testing.T) {
+		client := u.warcraftlogsOAuthClient("invalid_client_id", "invalid_client_secret", "")
+		_, err := client.GetGuild(context.Background(), "divine intervention", "darkspear", "eu")
+		if !errors.Is(err, api.ErrUnauthorized) {
+			t.Fatalf("Expected ErrUnauthorized with invalid client credentials, got %v", err)
+		}
+	})
+}